@@ -7,12 +7,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/db"
+
 	libs "github.com/k8guard/k8guardlibs"
 	"github.com/k8guard/k8guardlibs/violations"
 )
 
 type Action interface {
-	DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string
+	DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction
 }
 
 type SingleReplicaAction struct {
@@ -99,119 +102,143 @@ type NoOwnerAction struct {
 	violations.Violation
 }
 
+// Violation types there is an action for, see createAction in the messaging package.
+var knownViolationTypes = []violations.ViolationType{
+	violations.SINGLE_REPLICA_TYPE,
+	violations.IMAGE_SIZE_TYPE,
+	violations.IMAGE_REPO_TYPE,
+	violations.INGRESS_HOST_INVALID_TYPE,
+	violations.CAPABILITIES_TYPE,
+	violations.PRIVILEGED_TYPE,
+	violations.HOST_VOLUMES_TYPE,
+	violations.REQUIRED_NAMESPACES_TYPE,
+	violations.REQUIRED_NAMESPACE_ANNOTATIONS_TYPE,
+	violations.REQUIRED_NAMESPACE_LABELS_TYPE,
+	violations.REQUIRED_DEPLOYMENTS_TYPE,
+	violations.REQUIRED_DEPLOYMENT_ANNOTATIONS_TYPE,
+	violations.REQUIRED_DEPLOYMENT_LABELS_TYPE,
+	violations.REQUIRED_PODS_TYPE,
+	violations.REQUIRED_POD_ANNOTATIONS_TYPE,
+	violations.REQUIRED_POD_LABELS_TYPE,
+	violations.REQUIRED_DAEMONSETS_TYPE,
+	violations.REQUIRED_DAEMONSET_ANNOTATIONS_TYPE,
+	violations.REQUIRED_DAEMONSET_LABELS_TYPE,
+	violations.REQUIRED_RESOURCEQUOTA_TYPE,
+	violations.NO_OWNER_ANNOTATION_TYPE,
+}
+
+func isKnownViolationType(violationType violations.ViolationType) bool {
+	for _, t := range knownViolationTypes {
+		if t == violationType {
+			return true
+		}
+	}
+	return false
+}
+
 // action for containers with extra capablities.
-func (a CapabilitiesAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Extra Capabilities", a.Violation.Source, a.Type)
+func (a CapabilitiesAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Extra Capabilities", a.Violation.Source, a.Type)
 }
 
 // Action for privileged mode containers
-func (a PrivilegedAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Privileged Mode", a.Violation.Source, a.Type)
+func (a PrivilegedAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Privileged Mode", a.Violation.Source, a.Type)
 }
 
 // Action for any pod with a hostVolume
-func (a HostVolumesAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Host Volumes Mounted", a.Violation.Source, a.Type)
+func (a HostVolumesAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Host Volumes Mounted", a.Violation.Source, a.Type)
 }
 
-// action for pods with single replica , currently action is supressed.
-func (a SingleReplicaAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processSupressedAction(entity, vEntity, lastActions, "Single Replica", a.Source, a.Type)
+// action for pods with single replica , the built-in escalation policy only notifies.
+func (a SingleReplicaAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Single Replica", a.Source, a.Type)
 }
 
 // action for a container with a big image size
-func (a ImageSizeAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processSupressedAction(entity, vEntity, lastActions, "Invalid Image Size", a.Source, a.Type)
+func (a ImageSizeAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Invalid Image Size", a.Source, a.Type)
 }
 
 // action for invalid repo for an image
-func (a ImageRepoAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Invalid Image Repo", a.Violation.Source, a.Type)
+func (a ImageRepoAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Invalid Image Repo", a.Violation.Source, a.Type)
 }
 
-// action for ingress, a special kind that we don't warn.
-func (a IngressAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	// While in safe mode last warning = false
-	actMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, "Invalid Ingress", a.Violation.Source, len(lastActions["notify"]), libs.Cfg.ActionSafeMode == false)
-	NotifyOfViolation(actMessage)
-	if libs.Cfg.ActionSafeMode == false {
-		entity.DoAction()
-	} else {
-		libs.Log.Debug("Skipping action for ", vEntity.Name, " ", a.Type, " due to safe mode.")
-		return []string{"notify"}
-	}
-
-	return []string{"notify", "entity_action"}
+// action for ingress, the built-in escalation policy acts without warnings.
+func (a IngressAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Invalid Ingress", a.Violation.Source, a.Type)
 }
 
 // action for missing mandatory namespace
-func (a RequiredNamespaceAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing required namespace", a.Violation.Source, a.Type)
+func (a RequiredNamespaceAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing required namespace", a.Violation.Source, a.Type)
 }
 
 // action for missing namespace annotation
-func (a RequiredNamespaceAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing namespace annotation", a.Violation.Source, a.Type)
+func (a RequiredNamespaceAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing namespace annotation", a.Violation.Source, a.Type)
 }
 
 // action for missing namespace label
-func (a RequiredNamespaceLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing namespace label", a.Violation.Source, a.Type)
+func (a RequiredNamespaceLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing namespace label", a.Violation.Source, a.Type)
 }
 
 // action for missing mandatory deployment
-func (a RequiredDeploymentAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing required deployment", a.Violation.Source, a.Type)
+func (a RequiredDeploymentAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing required deployment", a.Violation.Source, a.Type)
 }
 
 // action for missing namespace annotation
-func (a RequiredDeploymentAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing deployment annotation", a.Violation.Source, a.Type)
+func (a RequiredDeploymentAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing deployment annotation", a.Violation.Source, a.Type)
 }
 
 // action for missing namespace label
-func (a RequiredDeploymentLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing deployment label", a.Violation.Source, a.Type)
+func (a RequiredDeploymentLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing deployment label", a.Violation.Source, a.Type)
 }
 
 // action for missing mandatory pod
-func (a RequiredPodAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing required pod", a.Violation.Source, a.Type)
+func (a RequiredPodAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing required pod", a.Violation.Source, a.Type)
 }
 
 // action for missing pod annotation
-func (a RequiredPodAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing pod annotation", a.Violation.Source, a.Type)
+func (a RequiredPodAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing pod annotation", a.Violation.Source, a.Type)
 }
 
 // action for missing pod label
-func (a RequiredPodLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing pod label", a.Violation.Source, a.Type)
+func (a RequiredPodLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing pod label", a.Violation.Source, a.Type)
 }
 
 // action for missing mandatory daemonset
-func (a RequiredDaemonSetAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing required daemonset", a.Violation.Source, a.Type)
+func (a RequiredDaemonSetAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing required daemonset", a.Violation.Source, a.Type)
 }
 
 // action for missing daemonset annotation
-func (a RequiredDaemonSetAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing daemonset annotation", a.Violation.Source, a.Type)
+func (a RequiredDaemonSetAnnotationAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing daemonset annotation", a.Violation.Source, a.Type)
 }
 
 // action for missing daemonset label
-func (a RequiredDaemonSetLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing daemonset label", a.Violation.Source, a.Type)
+func (a RequiredDaemonSetLabelAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing daemonset label", a.Violation.Source, a.Type)
 }
 
 // action for missing mandatory resourcequota
-func (a RequiredResourceQuotaAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "Missing required resourcequota", a.Violation.Source, a.Type)
+func (a RequiredResourceQuotaAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "Missing required resourcequota", a.Violation.Source, a.Type)
 }
 
 // action for missing owner
-func (a NoOwnerAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time) []string {
-	return processAction(entity, vEntity, lastActions, "No owner", a.Violation.Source, a.Type)
+func (a NoOwnerAction) DoAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
+	return processAction(entity, vEntity, lastActions, state, dryRun, scope, "No owner", a.Violation.Source, a.Type)
 }
 
 func ConvertActionableEntityToViolatableEntity(entity ActionableEntity) (libs.ViolatableEntity, error) {
@@ -240,6 +267,15 @@ func ConvertActionableEntityToViolatableEntity(entity ActionableEntity) (libs.Vi
 	case ActionCronJob:
 		vEntity = t.ViolatableEntity
 		break
+	case ActionStatefulSet:
+		vEntity = t.ViolatableEntity
+		break
+	case ActionReplicaSet:
+		vEntity = t.ViolatableEntity
+		break
+	case ActionReplicationController:
+		vEntity = t.ViolatableEntity
+		break
 	default:
 		return libs.ViolatableEntity{}, errors.New(fmt.Sprintf("Unknown Actionable Entity Type %s", t))
 	}
@@ -247,39 +283,216 @@ func ConvertActionableEntityToViolatableEntity(entity ActionableEntity) (libs.Vi
 	return vEntity, nil
 }
 
-func processAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, violationMessage string, violationSource string, violationType violations.ViolationType) []string {
-	lastTimeWarned, doIt := getLastTimeWarnedAndifToDoAction(lastActions)
-	if doIt {
-		entity.DoAction()
-		return []string{"entity_action"}
+// processAction warns about the violation and acts once enough warnings were sent,
+// as defined by the escalation policy of the violation type and the decision policy.
+func processAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope, violationMessage string, violationSource string, violationType violations.ViolationType) []DoneAction {
+	observeGoverned(governingPolicies(vEntity.Namespace), vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, time.Now())
+	policy := escalationForNamespace(vEntity.Namespace, violationType)
+	policy = policy.forOffenses(db.SelectOffenseRows(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name), currentEscalationPolicy().RepeatOffenders)
+	policy.DryRun = dryRun
+	if scope == NotifyScope {
+		policy.SafeMode = true
+		policy.Overrides = append(policy.Overrides, "notify scope")
+	}
+	libs.Log.Info("Escalating ", violationType, " of ", reflect.TypeOf(entity).Name(), " ", vEntity.Name, " in namespace ", vEntity.Namespace, " with ", policy)
+
+	decision := decide(entity, vEntity, lastActions, violationSource, violationType, policy)
+	switch decision.Decision {
+	case SkipDecision:
+		libs.Log.Info("Skipping ", vEntity.Name, " ", violationType, " as the decision policy decided: ", decision.Reason)
+		if t := lastActions[PolicySkipActionName]; len(t) > 0 && time.Now().Sub(t[len(t)-1]) < policy.NotifyInterval {
+			return []DoneAction{}
+		}
+		// recorded once every notify interval
+		return []DoneAction{{Name: PolicySkipActionName, Status: SkippedStatus, Reason: decision.Reason}}
+	case NotifyDecision:
+		policy.SafeMode = true
+	case ActDecision:
+		policy.WarningCount = 0
+	}
+	policy.DecisionReason = decision.Reason
+
+	doneActions := escalate(entity, vEntity, lastActions, state, violationMessage, violationSource, violationType, policy)
+	for i := range doneActions {
+		doneActions[i].Reason = decision.Reason
+	}
+	return doneActions
+}
+
+// escalate notifies or acts as the resolved escalation policy says, the state of the violation decides
+// what comes next and the notifications in its history how many warnings were sent and when.
+func escalate(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, violationMessage string, violationSource string, violationType violations.ViolationType, policy escalation) []DoneAction {
+	violation := violations.Violation{Source: violationSource, Type: violationType}
+	if state == ActionedState {
+		if _, inEffect, undone := actionInEffect(entity, vEntity, violation, remediationFor(entity, vEntity.Namespace, violationType), policy.DryRun); inEffect || !undone {
+			// acted on once, a restore or the violation resolving starts its lifecycle over
+			libs.Log.Debug("Skipping ", vEntity.Name, " ", violationType, " it was already acted on.")
+			return []DoneAction{}
+		}
+		libs.Log.Info("The action on ", vEntity.Name, " for ", violationType, " was undone, escalating again")
+		undoneAction := DoneAction{Name: EntityUndoneActionName, Status: SuccessStatus, Detail: string(remediationFor(entity, vEntity.Namespace, violationType)), State: PendingActionState}
+		return append([]DoneAction{undoneAction}, escalate(entity, vEntity, lastActions, PendingActionState, violationMessage, violationSource, violationType, policy)...)
 	}
 
-	if canSkipNotification(lastTimeWarned) {
-		libs.Log.Debug("Skipping notification for ", vEntity.Name, " ", violationType, " it was notified less than ", libs.Cfg.DurationBetweenNotifyingAgain, " ago.")
-		return []string{}
+	canAct := policy.Allowed && policy.SafeMode == false && remediationFor(entity, vEntity.Namespace, violationType) != NotifyOnlyRemediation
+	warnings := lastActions[NotifyActionName]
+
+	// an entity that was already warned is past its grace period
+	if state == "" || state == ObservedState || state == ResolvedState {
+		if graceEnd, inGrace := gracePeriodEnd(entity, time.Now()); inGrace {
+			if canSkipNotification(lastTimeWarned(lastActions), policy) {
+				return []DoneAction{}
+			}
+			aMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(warnings), false, policy)
+			aMessage.GracePeriodEnd = graceEnd.Format("Mon 2006-01-02 15:04 MST")
+			NotifyOfViolation(aMessage)
+			// not a notify, so it does not count toward the warnings before action
+			return []DoneAction{{Name: NotifyGraceActionName, Status: SuccessStatus, State: ObservedState}}
+		}
 	}
 
-	aMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(lastActions["notify"]), isLastWarning(lastActions))
+	if canAct && (state == LastWarningState || state == PendingActionState || len(warnings) >= policy.WarningCount) {
+		if stateRow, inEffect, _ := actionInEffect(entity, vEntity, violation, remediationFor(entity, vEntity.Namespace, violationType), policy.DryRun); inEffect {
+			// acting again would record the state after the action as the one to restore
+			libs.Log.Debug("Skipping action for ", vEntity.Name, " ", violationType, " the ", stateRow.Remediation, " at ", stateRow.CreatedAt, " was not restored yet.")
+			return []DoneAction{}
+		}
+
+		if next, deferred := deferredUntil(remediationFor(entity, vEntity.Namespace, violationType), time.Now()); deferred {
+			if canSkipNotification(lastTimeWarned(lastActions), policy) {
+				return []DoneAction{}
+			}
+			libs.Log.Info("Deferring action on ", vEntity.Name, " for ", violationType, " to ", next)
+			aMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(warnings), true, policy)
+			aMessage.NextEnforcement = next
+			NotifyOfViolation(aMessage)
+			return []DoneAction{{Name: NotifyActionName, Status: SuccessStatus}, {Name: EntityActionDeferredName, Status: DeferredStatus, Detail: next, State: PendingActionState}}
+		}
+
+		if !policy.DryRun && isSupportedRemediation(destructiveRemediations, remediationFor(entity, vEntity.Namespace, violationType)) && breaker.blocks(breakerDescription(entity, vEntity, violationType)) {
+			// taken once the breaker is reset
+			libs.Log.Debug("Skipping action for ", vEntity.Name, " ", violationType, " it is blocked by the circuit breaker.")
+			return []DoneAction{}
+		}
+
+		var pendingAction db.PendingActionRow
+		// shadow mode records the action as if it was approved
+		if policy.RequireApproval && !policy.DryRun && isSupportedRemediation(destructiveRemediations, remediationFor(entity, vEntity.Namespace, violationType)) {
+			var approved bool
+			var approvalActions []DoneAction
+			pendingAction, approved, approvalActions = approvalOf(entity, vEntity, violationMessage, violationSource, violationType, len(warnings), policy)
+			if !approved {
+				return approvalActions
+			}
+		}
+
+		doneActions := []DoneAction{}
+		if policy.WarningCount == 0 {
+			// nobody was warned, notify about the action instead
+			NotifyOfViolation(createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(warnings), true, policy))
+			doneActions = append(doneActions, DoneAction{Name: NotifyActionName, Status: SuccessStatus})
+		}
+
+		outcome := doEntityAction(entity, vEntity, violationSource, violationType, policy.DryRun)
+		if len(pendingAction.ID) > 0 && !outcome.Status.Retry() {
+			pendingAction.Status = string(ExecutedStatus)
+			db.UpdatePendingActionRow(pendingAction)
+		}
+		if isOffense(state, outcome.Status) {
+			db.InsertOffenseRow(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, string(violationType), violationSource, string(remediationFor(entity, vEntity.Namespace, violationType)), config.Cfg.OffenseRetention)
+		}
+		if outcome.Status == SuccessStatus && len(outcome.Detail) > 0 {
+			aMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(warnings), false, policy)
+			aMessage.AppliedFix = outcome.Detail
+			NotifyOfViolation(aMessage)
+		}
+		return append(doneActions, DoneAction{Name: EntityActionName, Status: outcome.Status, Detail: outcome.Detail, State: actionedState(outcome.Status)})
+	}
+
+	if canSkipNotification(lastTimeWarned(lastActions), policy) {
+		libs.Log.Debug("Skipping notification for ", vEntity.Name, " ", violationType, " it was notified less than ", policy.NotifyInterval, " ago.")
+		return []DoneAction{}
+	}
+
+	// While in safe mode or when the action is not allowed there is no last warning.
+	lastWarning := canAct && len(warnings) >= policy.WarningCount-1
+	aMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(warnings), lastWarning, policy)
+	if lastWarning {
+		aMessage.NextEnforcement, _ = deferredUntil(remediationFor(entity, vEntity.Namespace, violationType), time.Now().Add(policy.NotifyInterval))
+	}
 	NotifyOfViolation(aMessage)
-	return []string{"notify"}
+	if lastWarning {
+		return []DoneAction{{Name: NotifyActionName, Status: SuccessStatus, State: LastWarningState}}
+	}
+	return []DoneAction{{Name: NotifyActionName, Status: SuccessStatus, State: WarnedState}}
 
 }
 
-func processSupressedAction(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, violationMessage string, violationSource string, violationType violations.ViolationType) []string {
-	lastTimeWarned, _ := getLastTimeWarnedAndifToDoAction(lastActions)
+// breakerDescription names a destructive action in the circuit breaker alerts.
+func breakerDescription(entity ActionableEntity, vEntity libs.ViolatableEntity, violationType violations.ViolationType) string {
+	return fmt.Sprintf("%s of %s %s in namespace %s for %s", remediationFor(entity, vEntity.Namespace, violationType),
+		strings.TrimPrefix(reflect.TypeOf(entity).Name(), "Action"), vEntity.Name, vEntity.Namespace, violationType)
+}
 
-	if canSkipNotification(lastTimeWarned) {
-		libs.Log.Debug("Skipping notification for ", vEntity.Name, " ", violationType, " it was notified less than ", libs.Cfg.DurationBetweenNotifyingAgain, " ago.")
-		return []string{}
+// runs the configured remediation for the violation on the entity,
+// for reversible remediations the state before the action is recorded first
+// and entities are archived before they are deleted.
+func doEntityAction(entity ActionableEntity, vEntity libs.ViolatableEntity, violationSource string, violationType violations.ViolationType, dryRun bool) ActionOutcome {
+	remediation := remediationFor(entity, vEntity.Namespace, violationType)
+	entityType := reflect.TypeOf(entity).Name()
+	if dryRun {
+		libs.Log.Info("Shadow mode, would take action ", remediation, " on ", entityType, " ", vEntity.Name, " for ", violationType)
+		return ActionOutcome{Status: ShadowStatus, Detail: string(remediation)}
 	}
+	libs.Log.Info("Taking action ", remediation, " on ", entityType, " for ", violationType)
 
-	aMessage := createActionMessage(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationMessage, violationSource, len(lastActions["notify"]), isLastWarning(lastActions))
-	NotifyOfViolation(aMessage)
-	return []string{"notify"}
+	if isSupportedRemediation(destructiveRemediations, remediation) {
+		if !breaker.admit(vEntity.Namespace, breakerDescription(entity, vEntity, violationType), time.Now()) {
+			return ActionOutcome{Status: BlockedStatus, Err: errors.New("the circuit breaker is tripped")}
+		}
+	}
 
+	if reversible, ok := entity.(ReversibleEntity); ok && isSupportedRemediation(reversibleRemediations, remediation) {
+		state, err := reversible.CurrentState()
+		if err != nil {
+			libs.Log.Error("Not taking action on ", entityType, " ", vEntity.Name, " as its state could not be recorded: ", err)
+			return outcomeOf(err)
+		}
+		db.InsertEntityStateRow(vEntity.Namespace, entityType, vEntity.Name, string(violationType), violationSource, string(remediation), state)
+	}
+
+	if remediation == DeleteRemediation {
+		err := archiveEntity(entity, vEntity, violationSource, string(violationType))
+		if err != nil {
+			libs.Log.Error("Not deleting ", entityType, " ", vEntity.Name, " as it could not be archived: ", err)
+			return outcomeOf(err)
+		}
+	}
+
+	violation := violations.Violation{Source: violationSource, Type: violationType}
+	var outcome ActionOutcome
+	if remediation == QuarantineRemediation {
+		outcome = quarantineEntity(entity, vEntity, violation)
+		if outcome.Status == SuccessStatus {
+			// recorded so restoring the entity removes the network policy
+			db.InsertEntityStateRow(vEntity.Namespace, entityType, vEntity.Name, string(violationType), violationSource, string(remediation),
+				map[string]string{"networkPolicy": outcome.Detail})
+		}
+	} else {
+		outcome = entity.DoAction(remediation, violation)
+	}
+	if outcome.Status != SuccessStatus {
+		libs.Log.Error("Action ", remediation, " on ", entityType, " ", vEntity.Name, " in namespace ", vEntity.Namespace, " ended with ", outcome.Status, ": ", outcome.Err)
+		return outcome
+	}
+	if isSupportedRemediation(destructiveRemediations, remediation) {
+		// skipped, not found or failed actions did not change anything
+		breaker.count(vEntity.Namespace, time.Now())
+	}
+	return outcome
 }
 
-func createActionMessage(namespace string, entityType string, sourceName string, violationType string, violationSource string, warningCount int, lastWarning bool) actionMessage {
+func createActionMessage(namespace string, entityType string, sourceName string, violationType string, violationSource string, warningCount int, lastWarning bool, policy escalation) actionMessage {
 
 	aMessage := actionMessage{
 		Namespace:       namespace,
@@ -291,32 +504,29 @@ func createActionMessage(namespace string, entityType string, sourceName string,
 		WarningCount:    warningCount + 1,
 		// There will be no last warning in safe mode.
 		LastWarning: lastWarning,
+		Severity:    policy.Severity,
+		// empty without a decision policy
+		DecisionReason: policy.DecisionReason,
+		OffenseHistory: offenseHistory(policy.Offenses),
+		policy:         policy,
 	}
 
 	return aMessage
 
 }
 
-func canSkipNotification(lastTimeWarned time.Time) bool {
-	return lastTimeWarned.IsZero() == false && time.Now().Sub(lastTimeWarned) < libs.Cfg.DurationBetweenNotifyingAgain
+func canSkipNotification(lastTimeWarned time.Time, policy escalation) bool {
+	return lastTimeWarned.IsZero() == false && time.Now().Sub(lastTimeWarned) < policy.NotifyInterval
 
 }
 
-func getLastTimeWarnedAndifToDoAction(lastActions map[string][]time.Time) (time.Time, bool) {
-	var lastTimeWarned time.Time
-	var doAction = false
-
-	if t, ok := lastActions["notify"]; ok {
-		lastTimeWarned = t[len(t)-1]
-		if libs.Cfg.ActionSafeMode == false {
-			if len(t) >= libs.Cfg.WarningCountBeforeAction {
-				doAction = true
-			}
+// the last notification, including the ones in the grace period
+func lastTimeWarned(lastActions map[string][]time.Time) time.Time {
+	last := time.Time{}
+	for _, action := range []string{NotifyActionName, NotifyGraceActionName} {
+		if t, ok := lastActions[action]; ok && len(t) > 0 && t[len(t)-1].After(last) {
+			last = t[len(t)-1]
 		}
 	}
-	return lastTimeWarned, doAction
-}
-
-func isLastWarning(lastActions map[string][]time.Time) bool {
-	return libs.Cfg.ActionSafeMode == false && len(lastActions["notify"]) >= libs.Cfg.WarningCountBeforeAction-1
+	return last
 }
@@ -0,0 +1,119 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+// Statuses of a destructive action that needs approval.
+const (
+	PendingApprovalStatus ActionStatus = "pending-approval"
+	ApprovedStatus        ActionStatus = "approved"
+	RejectedStatus        ActionStatus = "rejected"
+	ExpiredStatus         ActionStatus = "expired"
+	// The approved action was taken.
+	ExecutedStatus ActionStatus = "executed"
+)
+
+var ErrNoPendingAction = errors.New("No such pending action")
+
+// approvalOf tells if the due action on the entity was approved, otherwise it requests an approval
+// unless one is pending or a rejection still holds. The pending action is returned to be marked executed.
+func approvalOf(entity ActionableEntity, vEntity libs.ViolatableEntity, violationMessage string, violationSource string, violationType violations.ViolationType, warningCount int, policy escalation) (db.PendingActionRow, bool, []DoneAction) {
+	entityType := reflect.TypeOf(entity).Name()
+	now := time.Now()
+
+	pendingAction, found := db.SelectLatestPendingActionRow(vEntity.Namespace, entityType, vEntity.Name, string(violationType), violationSource)
+	if found {
+		switch ActionStatus(pendingAction.Status) {
+		case ApprovedStatus:
+			return pendingAction, true, nil
+		case PendingApprovalStatus:
+			if now.Before(pendingAction.ExpiresAt) {
+				libs.Log.Debug("Action on ", vEntity.Name, " for ", violationType, " waits for approval ", pendingAction.ID)
+				return pendingAction, false, []DoneAction{}
+			}
+			libs.Log.Info("Approval ", pendingAction.ID, " of the action on ", vEntity.Name, " for ", violationType, " expired")
+			pendingAction.Status = string(ExpiredStatus)
+			pendingAction.DecidedAt = now
+			db.UpdatePendingActionRow(pendingAction)
+			return pendingAction, false, []DoneAction{{Name: ApprovalActionName, Status: ExpiredStatus, Detail: pendingAction.ID}}
+		case RejectedStatus:
+			if now.Before(pendingAction.ExpiresAt) {
+				return pendingAction, false, []DoneAction{}
+			}
+		}
+	}
+
+	pendingAction = db.PendingActionRow{
+		Namespace:   vEntity.Namespace,
+		Type:        entityType,
+		Source:      vEntity.Name,
+		VType:       string(violationType),
+		VSource:     violationSource,
+		Severity:    string(policy.Severity),
+		Remediation: string(remediationFor(entity, vEntity.Namespace, violationType)),
+		Status:      string(PendingApprovalStatus),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(config.Cfg.ApprovalTimeout),
+	}
+	pendingAction.ID = db.InsertPendingActionRow(pendingAction)
+	libs.Log.Info("Requested approval ", pendingAction.ID, " for ", pendingAction.Remediation, " of ", vEntity.Name, " for ", violationType)
+
+	aMessage := createActionMessage(vEntity.Namespace, entityType, vEntity.Name, violationMessage, violationSource, warningCount, true, policy)
+	aMessage.PendingApproval = fmt.Sprintf("%s of request %s, it expires %s", pendingAction.Remediation, pendingAction.ID, pendingAction.ExpiresAt.Format("Mon 2006-01-02 15:04 MST"))
+	NotifyOfViolation(aMessage)
+	return pendingAction, false, []DoneAction{{Name: EntityActionPendingName, Status: PendingApprovalStatus, Detail: pendingAction.ID, State: PendingActionState}}
+}
+
+// ListPendingActions returns the pending actions of the cluster, the ones nobody decided on in time as expired.
+func ListPendingActions() []db.PendingActionRow {
+	pendingActions := db.SelectPendingActionRows()
+	for i := range pendingActions {
+		if ActionStatus(pendingActions[i].Status) == PendingApprovalStatus && time.Now().After(pendingActions[i].ExpiresAt) {
+			pendingActions[i].Status = string(ExpiredStatus)
+		}
+	}
+	return pendingActions
+}
+
+// DecidePendingAction approves or rejects a pending action and records who did it in the action log.
+// A rejection holds for the approval timeout, after that the action is requested again.
+func DecidePendingAction(id string, approve bool, decidedBy string, reason string) (db.PendingActionRow, error) {
+	pendingAction, found := db.SelectPendingActionRow(id)
+	if !found {
+		return pendingAction, ErrNoPendingAction
+	}
+	if ActionStatus(pendingAction.Status) != PendingApprovalStatus {
+		return pendingAction, fmt.Errorf("Pending action %s is already %s", id, pendingAction.Status)
+	}
+	now := time.Now()
+	if now.After(pendingAction.ExpiresAt) {
+		return pendingAction, fmt.Errorf("Pending action %s expired at %s", id, pendingAction.ExpiresAt)
+	}
+
+	status := RejectedStatus
+	if approve {
+		status = ApprovedStatus
+	} else {
+		pendingAction.ExpiresAt = now.Add(config.Cfg.ApprovalTimeout)
+	}
+	pendingAction.Status = string(status)
+	pendingAction.DecidedBy = decidedBy
+	pendingAction.DecidedAt = now
+	pendingAction.Reason = reason
+	db.UpdatePendingActionRow(pendingAction)
+
+	db.InsertActionLogRow(pendingAction.Namespace, pendingAction.Type, pendingAction.Source, pendingAction.VType, pendingAction.VSource,
+		pendingAction.Severity, ApprovalActionName, string(status), fmt.Sprintf("%s of request %s %s by %s: %s", pendingAction.Remediation, id, status, decidedBy, reason), "")
+	libs.Log.Info("Pending action ", id, " was ", status, " by ", decidedBy)
+	return pendingAction, nil
+}
@@ -0,0 +1,918 @@
+package actions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/db"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	appsv1beta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	extv1beta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	rbacv1beta1 "k8s.io/client-go/pkg/apis/rbac/v1beta1"
+)
+
+// Entities whose manifest is archived before they are deleted.
+type ArchivableEntity interface {
+	// The live object serialized as JSON.
+	Manifest() ([]byte, error)
+	// Creates the object again from an archived manifest.
+	Reapply(manifest []byte) error
+}
+
+// Entities whose contents are archived with them one row per object, e.g. everything that goes away with a namespace.
+type ContentArchivableEntity interface {
+	// The objects serialized as JSON, keyed by Kind/name.
+	Contents() (map[string][]byte, error)
+	// Creates the objects again after the entity was reapplied.
+	ReapplyContents(contents map[string][]byte) error
+}
+
+// The kinds archived with a namespace, in the order they are created again.
+var namespaceContentKinds = []string{"ServiceAccount", "Secret", "PersistentVolumeClaim", "Role", "RoleBinding", "ResourceQuota", "LimitRange",
+	"NetworkPolicy", "ConfigMap", "Service", "Deployment", "StatefulSet", "ReplicaSet", "ReplicationController", "DaemonSet", "Job", "Pod",
+	"Ingress", "CronJob"}
+
+// ReapplyArchivedEntity creates an entity again from its last archived manifest,
+// marks it in the action log and restarts the warnings for the violation.
+func ReapplyArchivedEntity(kind string, namespace string, name string) error {
+	entity, err := newActionableEntity(kind, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	archivable, ok := entity.(ArchivableEntity)
+	if !ok {
+		return errors.New(fmt.Sprintf("%s does not support archiving", kind))
+	}
+
+	entityType := reflect.TypeOf(entity).Name()
+	archiveRow, found := db.SelectLatestArchiveRow(namespace, entityType, name)
+	if !found {
+		return errors.New(fmt.Sprintf("No archived manifest for %s %s in namespace %s", kind, name, namespace))
+	}
+	if archiveRow.ReappliedAt.IsZero() == false {
+		libs.Log.Warn(kind, " ", name, " in namespace ", namespace, " was already reapplied at ", archiveRow.ReappliedAt)
+	}
+
+	libs.Log.Info("Reapplying ", entityType, " ", name, " in namespace ", namespace, " archived at ", archiveRow.CreatedAt)
+	err = archivable.Reapply([]byte(archiveRow.Manifest))
+	if err != nil {
+		return err
+	}
+	if contentArchivable, ok := entity.(ContentArchivableEntity); ok {
+		err = contentArchivable.ReapplyContents(db.SelectArchiveContentRows(namespace, entityType, name, archiveRow.CreatedAt))
+		if err != nil {
+			// the entity is back, the problems are reported and the reapply is still recorded
+			libs.Log.Error(err)
+		}
+	}
+
+	db.MarkArchiveRowReapplied(archiveRow)
+	db.InsertActionLogRow(namespace, entityType, name, archiveRow.VType, archiveRow.VSource, severityOfType(archiveRow.VType), EntityReapplyActionName, string(SuccessStatus), "", "")
+	// start warning again instead of deleting on the next scan
+	restartLifecycle(namespace, entityType, name, violations.Violation{Type: violations.ViolationType(archiveRow.VType), Source: archiveRow.VSource}, EntityReapplyActionName)
+
+	return nil
+}
+
+// ListArchivedEntities returns the archived entities of a namespace, newest first per entity.
+func ListArchivedEntities(namespace string) []db.ArchiveRow {
+	archiveRows := db.SelectArchiveRows(namespace)
+	for i := range archiveRows {
+		archiveRows[i].Type = strings.TrimPrefix(archiveRows[i].Type, "Action")
+	}
+	return archiveRows
+}
+
+// archiveEntity stores the manifest of the entity before it is deleted.
+func archiveEntity(entity ActionableEntity, vEntity libs.ViolatableEntity, violationSource string, violationType string) error {
+	archivable, ok := entity.(ArchivableEntity)
+	if !ok {
+		return nil
+	}
+
+	manifest, err := archivable.Manifest()
+	if err != nil {
+		return err
+	}
+
+	var contents map[string][]byte
+	if contentArchivable, ok := entity.(ContentArchivableEntity); ok {
+		contents, err = contentArchivable.Contents()
+		if err != nil {
+			return err
+		}
+	}
+
+	// the contents first, an archive row without them would reapply an empty entity
+	archivedAt := time.Now()
+	for key, manifest := range contents {
+		parts := strings.SplitN(key, "/", 2)
+		db.InsertArchiveContentRow(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, archivedAt, parts[0], parts[1], manifest)
+	}
+	db.InsertArchiveRow(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name, violationType, violationSource, manifest, archivedAt)
+	return nil
+}
+
+// clearServerFields removes the fields set by the api server so an archived object can be created again.
+func clearServerFields(meta *metav1.ObjectMeta) {
+	meta.ResourceVersion = ""
+	meta.UID = ""
+	meta.SelfLink = ""
+	meta.Generation = 0
+	meta.CreationTimestamp = metav1.Time{}
+	meta.DeletionTimestamp = nil
+	meta.DeletionGracePeriodSeconds = nil
+	// the owners are most likely gone too, the garbage collector would delete the object again
+	meta.OwnerReferences = nil
+}
+
+func (a ActionPod) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kp, err := clientset.CoreV1().Pods(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kp)
+}
+
+func (a ActionPod) Reapply(manifest []byte) error {
+	kp := &v1.Pod{}
+	err := json.Unmarshal(manifest, kp)
+	if err != nil {
+		return err
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().Pods(a.Namespace).Create(cleanPod(kp))
+	return err
+}
+
+func cleanPod(kp *v1.Pod) *v1.Pod {
+	clearServerFields(&kp.ObjectMeta)
+	kp.Spec.NodeName = ""
+	kp.Status = v1.PodStatus{}
+	return kp
+}
+
+func (a ActionDeployment) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kd)
+}
+
+func (a ActionDeployment) Reapply(manifest []byte) error {
+	kd := &appsv1beta1.Deployment{}
+	err := json.Unmarshal(manifest, kd)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&kd.ObjectMeta)
+	kd.Status = appsv1beta1.DeploymentStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.AppsV1beta1().Deployments(a.Namespace).Create(kd)
+	return err
+}
+
+func (a ActionDaemonSet) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kds, err := clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kds)
+}
+
+func (a ActionDaemonSet) Reapply(manifest []byte) error {
+	kds := &extv1beta1.DaemonSet{}
+	err := json.Unmarshal(manifest, kds)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&kds.ObjectMeta)
+	kds.Status = extv1beta1.DaemonSetStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Create(kds)
+	return err
+}
+
+func (a ActionIngress) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	ki, err := clientset.ExtensionsV1beta1().Ingresses(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ki)
+}
+
+func (a ActionIngress) Reapply(manifest []byte) error {
+	ki := &extv1beta1.Ingress{}
+	err := json.Unmarshal(manifest, ki)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&ki.ObjectMeta)
+	ki.Status = extv1beta1.IngressStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.ExtensionsV1beta1().Ingresses(a.Namespace).Create(ki)
+	return err
+}
+
+func (a ActionJob) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kj, err := clientset.BatchV1().Jobs(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kj)
+}
+
+func (a ActionJob) Reapply(manifest []byte) error {
+	kj := &batchv1.Job{}
+	err := json.Unmarshal(manifest, kj)
+	if err != nil {
+		return err
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.BatchV1().Jobs(a.Namespace).Create(cleanJob(kj))
+	return err
+}
+
+// The selector and its labels are generated from the uid of the old job, let the api server generate new ones.
+func cleanJob(kj *batchv1.Job) *batchv1.Job {
+	clearServerFields(&kj.ObjectMeta)
+	kj.Status = batchv1.JobStatus{}
+	if kj.Spec.ManualSelector == nil || *kj.Spec.ManualSelector == false {
+		kj.Spec.Selector = nil
+		delete(kj.Spec.Template.Labels, "controller-uid")
+		delete(kj.Spec.Template.Labels, "job-name")
+	}
+	return kj
+}
+
+func (a ActionCronJob) Manifest() ([]byte, error) {
+	if libs.Cfg.IncludeAlpha == false {
+		return nil, fmt.Errorf("CronJob %s is ignored as alpha features are not enabled", a.Name)
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kcj, err := clientset.BatchV2alpha1().CronJobs(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kcj)
+}
+
+func (a ActionCronJob) Reapply(manifest []byte) error {
+	if libs.Cfg.IncludeAlpha == false {
+		return fmt.Errorf("CronJob %s is ignored as alpha features are not enabled", a.Name)
+	}
+	kcj := &batchv2alpha1.CronJob{}
+	err := json.Unmarshal(manifest, kcj)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&kcj.ObjectMeta)
+	kcj.Status = batchv2alpha1.CronJobStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.BatchV2alpha1().CronJobs(a.Namespace).Create(kcj)
+	return err
+}
+
+func (a ActionStatefulSet) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kss, err := clientset.AppsV1beta1().StatefulSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kss)
+}
+
+func (a ActionStatefulSet) Reapply(manifest []byte) error {
+	kss := &appsv1beta1.StatefulSet{}
+	err := json.Unmarshal(manifest, kss)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&kss.ObjectMeta)
+	kss.Status = appsv1beta1.StatefulSetStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.AppsV1beta1().StatefulSets(a.Namespace).Create(kss)
+	return err
+}
+
+func (a ActionReplicaSet) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	krs, err := clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(krs)
+}
+
+func (a ActionReplicaSet) Reapply(manifest []byte) error {
+	krs := &extv1beta1.ReplicaSet{}
+	err := json.Unmarshal(manifest, krs)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&krs.ObjectMeta)
+	krs.Status = extv1beta1.ReplicaSetStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Create(krs)
+	return err
+}
+
+func (a ActionReplicationController) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	krc, err := clientset.CoreV1().ReplicationControllers(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(krc)
+}
+
+func (a ActionReplicationController) Reapply(manifest []byte) error {
+	krc := &v1.ReplicationController{}
+	err := json.Unmarshal(manifest, krc)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&krc.ObjectMeta)
+	krc.Status = v1.ReplicationControllerStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().ReplicationControllers(a.Namespace).Create(krc)
+	return err
+}
+
+// Manifest of a namespace is the namespace alone, what goes away with it is archived by Contents.
+func (a ActionNamespace) Manifest() ([]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kns, err := clientset.CoreV1().Namespaces().Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kns)
+}
+
+// Reapply creates the namespace, its contents are created by ReapplyContents.
+func (a ActionNamespace) Reapply(manifest []byte) error {
+	kns := &v1.Namespace{}
+	err := json.Unmarshal(manifest, kns)
+	if err != nil {
+		return err
+	}
+	clearServerFields(&kns.ObjectMeta)
+	kns.Status = v1.NamespaceStatus{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().Namespaces().Create(kns)
+	return err
+}
+
+// Contents returns everything that goes away with the namespace keyed by Kind/name.
+// Service account tokens are left out, they are created again for the service accounts.
+func (a ActionNamespace) Contents() (map[string][]byte, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	contents := map[string][]byte{}
+	add := func(kind string, name string, object interface{}) error {
+		manifest, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		contents[kind+"/"+name] = manifest
+		return nil
+	}
+
+	serviceAccounts, err := clientset.CoreV1().ServiceAccounts(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ksa := range serviceAccounts.Items {
+		if err = add("ServiceAccount", ksa.Name, ksa); err != nil {
+			return nil, err
+		}
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ks := range secrets.Items {
+		if ks.Type == v1.SecretTypeServiceAccountToken {
+			continue
+		}
+		archived, err := sealSecret(ks)
+		if err != nil {
+			return nil, err
+		}
+		if err = add("Secret", ks.Name, archived); err != nil {
+			return nil, err
+		}
+	}
+
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kpvc := range claims.Items {
+		if err = add("PersistentVolumeClaim", kpvc.Name, kpvc); err != nil {
+			return nil, err
+		}
+	}
+
+	roles, err := clientset.RbacV1beta1().Roles(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kr := range roles.Items {
+		if err = add("Role", kr.Name, kr); err != nil {
+			return nil, err
+		}
+	}
+
+	roleBindings, err := clientset.RbacV1beta1().RoleBindings(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, krb := range roleBindings.Items {
+		if err = add("RoleBinding", krb.Name, krb); err != nil {
+			return nil, err
+		}
+	}
+
+	quotas, err := clientset.CoreV1().ResourceQuotas(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, krq := range quotas.Items {
+		// a freeze is not part of the namespace
+		if krq.Name == freezeQuotaName {
+			continue
+		}
+		if err = add("ResourceQuota", krq.Name, krq); err != nil {
+			return nil, err
+		}
+	}
+
+	limitRanges, err := clientset.CoreV1().LimitRanges(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, klr := range limitRanges.Items {
+		if err = add("LimitRange", klr.Name, klr); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := clientset.CoreV1().RESTClient().Get().AbsPath(networkPoliciesPath, a.Name, "networkpolicies").DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	// networking.k8s.io/v1 objects as returned by the api server, the typed client has no such group yet
+	networkPolicies := struct {
+		Items []json.RawMessage `json:"items"`
+	}{}
+	err = json.Unmarshal(raw, &networkPolicies)
+	if err != nil {
+		return nil, err
+	}
+	for _, knp := range networkPolicies.Items {
+		meta := struct {
+			Metadata metav1.ObjectMeta `json:"metadata"`
+		}{}
+		if err = json.Unmarshal(knp, &meta); err != nil {
+			return nil, err
+		}
+		contents["NetworkPolicy/"+meta.Metadata.Name] = knp
+	}
+
+	configMaps, err := clientset.CoreV1().ConfigMaps(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kcm := range configMaps.Items {
+		if err = add("ConfigMap", kcm.Name, kcm); err != nil {
+			return nil, err
+		}
+	}
+
+	services, err := clientset.CoreV1().Services(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ks := range services.Items {
+		if err = add("Service", ks.Name, ks); err != nil {
+			return nil, err
+		}
+	}
+
+	deployments, err := clientset.AppsV1beta1().Deployments(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kd := range deployments.Items {
+		if err = add("Deployment", kd.Name, kd); err != nil {
+			return nil, err
+		}
+	}
+
+	statefulSets, err := clientset.AppsV1beta1().StatefulSets(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kss := range statefulSets.Items {
+		if err = add("StatefulSet", kss.Name, kss); err != nil {
+			return nil, err
+		}
+	}
+
+	replicaSets, err := clientset.ExtensionsV1beta1().ReplicaSets(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, krs := range replicaSets.Items {
+		// replica sets of a deployment are created again by the deployment
+		if len(krs.OwnerReferences) > 0 {
+			continue
+		}
+		if err = add("ReplicaSet", krs.Name, krs); err != nil {
+			return nil, err
+		}
+	}
+
+	replicationControllers, err := clientset.CoreV1().ReplicationControllers(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, krc := range replicationControllers.Items {
+		if err = add("ReplicationController", krc.Name, krc); err != nil {
+			return nil, err
+		}
+	}
+
+	daemonSets, err := clientset.ExtensionsV1beta1().DaemonSets(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kds := range daemonSets.Items {
+		if err = add("DaemonSet", kds.Name, kds); err != nil {
+			return nil, err
+		}
+	}
+
+	jobs, err := clientset.BatchV1().Jobs(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kj := range jobs.Items {
+		// jobs of a cronjob are created again by the cronjob
+		if len(kj.OwnerReferences) > 0 {
+			continue
+		}
+		if err = add("Job", kj.Name, kj); err != nil {
+			return nil, err
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kp := range pods.Items {
+		// only bare pods, the others are created again by their controllers
+		if len(kp.OwnerReferences) > 0 {
+			continue
+		}
+		if err = add("Pod", kp.Name, kp); err != nil {
+			return nil, err
+		}
+	}
+
+	ingresses, err := clientset.ExtensionsV1beta1().Ingresses(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ki := range ingresses.Items {
+		if err = add("Ingress", ki.Name, ki); err != nil {
+			return nil, err
+		}
+	}
+
+	if libs.Cfg.IncludeAlpha {
+		cronJobs, err := clientset.BatchV2alpha1().CronJobs(a.Name).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, kcj := range cronJobs.Items {
+			if err = add("CronJob", kcj.Name, kcj); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return contents, nil
+}
+
+// ReapplyContents creates everything that was archived with the namespace, kind by kind in the order of
+// namespaceContentKinds. It carries on when single objects fail and returns all the errors at the end.
+func (a ActionNamespace) ReapplyContents(contents map[string][]byte) error {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+
+	problems := []string{}
+	for _, kind := range namespaceContentKinds {
+		names := []string{}
+		for key := range contents {
+			if strings.HasPrefix(key, kind+"/") {
+				names = append(names, strings.TrimPrefix(key, kind+"/"))
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			err = a.reapplyObject(clientset, kind, contents[kind+"/"+name])
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s %s: %s", kind, name, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Namespace " + a.Name + " was created but some objects failed: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (a ActionNamespace) reapplyObject(clientset kubernetes.Interface, kind string, manifest []byte) error {
+	var err error
+	switch kind {
+	case "ServiceAccount":
+		ksa := &v1.ServiceAccount{}
+		if err = json.Unmarshal(manifest, ksa); err != nil {
+			return err
+		}
+		clearServerFields(&ksa.ObjectMeta)
+		// the token secrets are created again
+		ksa.Secrets = nil
+		_, err = clientset.CoreV1().ServiceAccounts(a.Name).Create(ksa)
+		if apierrors.IsAlreadyExists(err) {
+			// e.g. the default service account
+			return nil
+		}
+	case "Secret":
+		archived := archivedSecret{}
+		if err = json.Unmarshal(manifest, &archived); err != nil {
+			return err
+		}
+		ks, err := openSecret(archived)
+		if err != nil {
+			return err
+		}
+		clearServerFields(&ks.ObjectMeta)
+		_, err = clientset.CoreV1().Secrets(a.Name).Create(&ks)
+		return err
+	case "PersistentVolumeClaim":
+		kpvc := &v1.PersistentVolumeClaim{}
+		if err = json.Unmarshal(manifest, kpvc); err != nil {
+			return err
+		}
+		clearServerFields(&kpvc.ObjectMeta)
+		// the volume went away with the claim, a new one is provisioned
+		kpvc.Spec.VolumeName = ""
+		delete(kpvc.Annotations, "pv.kubernetes.io/bind-completed")
+		delete(kpvc.Annotations, "pv.kubernetes.io/bound-by-controller")
+		kpvc.Status = v1.PersistentVolumeClaimStatus{}
+		_, err = clientset.CoreV1().PersistentVolumeClaims(a.Name).Create(kpvc)
+	case "Role":
+		kr := &rbacv1beta1.Role{}
+		if err = json.Unmarshal(manifest, kr); err != nil {
+			return err
+		}
+		clearServerFields(&kr.ObjectMeta)
+		_, err = clientset.RbacV1beta1().Roles(a.Name).Create(kr)
+	case "RoleBinding":
+		krb := &rbacv1beta1.RoleBinding{}
+		if err = json.Unmarshal(manifest, krb); err != nil {
+			return err
+		}
+		clearServerFields(&krb.ObjectMeta)
+		_, err = clientset.RbacV1beta1().RoleBindings(a.Name).Create(krb)
+	case "ResourceQuota":
+		krq := &v1.ResourceQuota{}
+		if err = json.Unmarshal(manifest, krq); err != nil {
+			return err
+		}
+		clearServerFields(&krq.ObjectMeta)
+		krq.Status = v1.ResourceQuotaStatus{}
+		_, err = clientset.CoreV1().ResourceQuotas(a.Name).Create(krq)
+	case "LimitRange":
+		klr := &v1.LimitRange{}
+		if err = json.Unmarshal(manifest, klr); err != nil {
+			return err
+		}
+		clearServerFields(&klr.ObjectMeta)
+		_, err = clientset.CoreV1().LimitRanges(a.Name).Create(klr)
+	case "NetworkPolicy":
+		knp := struct {
+			APIVersion string            `json:"apiVersion"`
+			Kind       string            `json:"kind"`
+			Metadata   metav1.ObjectMeta `json:"metadata"`
+			Spec       json.RawMessage   `json:"spec"`
+		}{}
+		if err = json.Unmarshal(manifest, &knp); err != nil {
+			return err
+		}
+		clearServerFields(&knp.Metadata)
+		knp.APIVersion, knp.Kind = "networking.k8s.io/v1", "NetworkPolicy"
+		body, err := json.Marshal(knp)
+		if err != nil {
+			return err
+		}
+		return clientset.CoreV1().RESTClient().Post().AbsPath(networkPoliciesPath, a.Name, "networkpolicies").Body(body).Do().Error()
+	case "ConfigMap":
+		kcm := &v1.ConfigMap{}
+		if err = json.Unmarshal(manifest, kcm); err != nil {
+			return err
+		}
+		clearServerFields(&kcm.ObjectMeta)
+		_, err = clientset.CoreV1().ConfigMaps(a.Name).Create(kcm)
+	case "Service":
+		ks := &v1.Service{}
+		if err = json.Unmarshal(manifest, ks); err != nil {
+			return err
+		}
+		clearServerFields(&ks.ObjectMeta)
+		if ks.Spec.ClusterIP != v1.ClusterIPNone {
+			ks.Spec.ClusterIP = ""
+		}
+		ks.Status = v1.ServiceStatus{}
+		_, err = clientset.CoreV1().Services(a.Name).Create(ks)
+	default:
+		// the workloads reapply like when they are deleted alone
+		entity, err := newActionableEntity(kind, a.Name, "")
+		if err != nil {
+			return err
+		}
+		archivable, ok := entity.(ArchivableEntity)
+		if !ok {
+			return fmt.Errorf("%s does not support archiving", kind)
+		}
+		return archivable.Reapply(manifest)
+	}
+	return err
+}
+
+// archivedSecret is a secret without its data, the data is sealed with K8GUARD_ACTION_ARCHIVE_SECRET_KEY
+// when there is one and left out otherwise.
+type archivedSecret struct {
+	Secret v1.Secret `json:"secret"`
+	Sealed []byte    `json:"sealed,omitempty"`
+}
+
+func sealSecret(ks v1.Secret) (archivedSecret, error) {
+	data := ks.Data
+	ks.Data = nil
+	ks.StringData = nil
+	archived := archivedSecret{Secret: ks}
+	if len(config.Cfg.ArchiveSecretKey) == 0 {
+		return archived, nil
+	}
+
+	aead, err := archiveSecretAEAD()
+	if err != nil {
+		return archivedSecret{}, err
+	}
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return archivedSecret{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return archivedSecret{}, err
+	}
+	archived.Sealed = aead.Seal(nonce, nonce, plain, []byte(ks.Namespace+"/"+ks.Name))
+	return archived, nil
+}
+
+func openSecret(archived archivedSecret) (v1.Secret, error) {
+	ks := archived.Secret
+	if len(archived.Sealed) == 0 {
+		return v1.Secret{}, errors.New("archived without its data, create it again by hand")
+	}
+
+	aead, err := archiveSecretAEAD()
+	if err != nil {
+		return v1.Secret{}, err
+	}
+	if len(archived.Sealed) < aead.NonceSize() {
+		return v1.Secret{}, errors.New("the sealed data is too short")
+	}
+	nonce, sealed := archived.Sealed[:aead.NonceSize()], archived.Sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(ks.Namespace+"/"+ks.Name))
+	if err != nil {
+		return v1.Secret{}, err
+	}
+	err = json.Unmarshal(plain, &ks.Data)
+	return ks, err
+}
+
+// The key is base64 encoded, 16, 24 or 32 bytes for AES-128, AES-192 or AES-256.
+func archiveSecretAEAD() (cipher.AEAD, error) {
+	if len(config.Cfg.ArchiveSecretKey) == 0 {
+		return nil, errors.New("no K8GUARD_ACTION_ARCHIVE_SECRET_KEY to open the sealed data")
+	}
+	key, err := base64.StdEncoding.DecodeString(config.Cfg.ArchiveSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid K8GUARD_ACTION_ARCHIVE_SECRET_KEY: %s", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid K8GUARD_ACTION_ARCHIVE_SECRET_KEY: %s", err)
+	}
+	return cipher.NewGCM(block)
+}
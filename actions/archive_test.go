@@ -0,0 +1,56 @@
+package actions
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/k8guard/k8guard-action/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestSealSecret(t *testing.T) {
+	defer func(key string) { config.Cfg.ArchiveSecretKey = key }(config.Cfg.ArchiveSecretKey)
+	ks := v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "db"}, Data: map[string][]byte{"password": []byte("hunter2")}}
+
+	config.Cfg.ArchiveSecretKey = ""
+	archived, err := sealSecret(ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived.Secret.Data) > 0 || len(archived.Sealed) > 0 {
+		t.Errorf("secret archived with its data without a key")
+	}
+	if _, err = openSecret(archived); err == nil {
+		t.Errorf("opened a secret archived without its data")
+	}
+
+	config.Cfg.ArchiveSecretKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	archived, err = sealSecret(ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived.Secret.Data) > 0 || len(archived.Sealed) == 0 {
+		t.Fatalf("secret data not sealed")
+	}
+	opened, err := openSecret(archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(opened.Data["password"]) != "hunter2" {
+		t.Errorf("opened password %q, want %q", opened.Data["password"], "hunter2")
+	}
+
+	// sealed for another secret
+	archived.Secret.Name = "other"
+	if _, err = openSecret(archived); err == nil {
+		t.Errorf("opened the data sealed for another secret")
+	}
+
+	config.Cfg.ArchiveSecretKey = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	archived.Secret.Name = "db"
+	if _, err = openSecret(archived); err == nil {
+		t.Errorf("opened the data with another key")
+	}
+}
@@ -0,0 +1,111 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/k8guard/k8guardlibs/violations"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Violation types the auto-fix remediation knows how to fix.
+var autoFixableViolationTypes = []violations.ViolationType{
+	violations.PRIVILEGED_TYPE,
+	violations.CAPABILITIES_TYPE,
+	violations.HOST_VOLUMES_TYPE,
+}
+
+func isAutoFixable(violationType violations.ViolationType) bool {
+	for _, t := range autoFixableViolationTypes {
+		if t == violationType {
+			return true
+		}
+	}
+	return false
+}
+
+// autoFixPatch builds the strategic merge patch that removes the violation from a pod template,
+// it turns off privileged mode, drops added capabilities or removes host path volumes and their mounts.
+func autoFixPatch(podSpec v1.PodSpec, violationType violations.ViolationType) ([]byte, error) {
+	templateSpec := map[string]interface{}{}
+
+	switch violationType {
+	case violations.PRIVILEGED_TYPE:
+		fix := func(c v1.Container) map[string]interface{} {
+			if c.SecurityContext == nil || c.SecurityContext.Privileged == nil || *c.SecurityContext.Privileged == false {
+				return nil
+			}
+			return map[string]interface{}{"name": c.Name, "securityContext": map[string]interface{}{"privileged": false}}
+		}
+		addContainerFixes(templateSpec, podSpec, fix)
+	case violations.CAPABILITIES_TYPE:
+		fix := func(c v1.Container) map[string]interface{} {
+			if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil || len(c.SecurityContext.Capabilities.Add) == 0 {
+				return nil
+			}
+			// null deletes the field in a strategic merge patch
+			return map[string]interface{}{"name": c.Name, "securityContext": map[string]interface{}{"capabilities": map[string]interface{}{"add": nil}}}
+		}
+		addContainerFixes(templateSpec, podSpec, fix)
+	case violations.HOST_VOLUMES_TYPE:
+		hostVolumes := map[string]bool{}
+		volumes := []map[string]interface{}{}
+		for _, volume := range podSpec.Volumes {
+			if volume.HostPath != nil {
+				hostVolumes[volume.Name] = true
+				volumes = append(volumes, map[string]interface{}{"name": volume.Name, "$patch": "delete"})
+			}
+		}
+		if len(volumes) > 0 {
+			templateSpec["volumes"] = volumes
+		}
+		fix := func(c v1.Container) map[string]interface{} {
+			mounts := []map[string]interface{}{}
+			for _, mount := range c.VolumeMounts {
+				if hostVolumes[mount.Name] {
+					mounts = append(mounts, map[string]interface{}{"mountPath": mount.MountPath, "$patch": "delete"})
+				}
+			}
+			if len(mounts) == 0 {
+				return nil
+			}
+			return map[string]interface{}{"name": c.Name, "volumeMounts": mounts}
+		}
+		addContainerFixes(templateSpec, podSpec, fix)
+	default:
+		return nil, fmt.Errorf("%s can not be fixed automatically", violationType)
+	}
+
+	if len(templateSpec) == 0 {
+		return nil, errors.New("the pod template has nothing to fix for " + string(violationType))
+	}
+
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": templateSpec},
+		},
+	}
+	return json.Marshal(patch)
+}
+
+func addContainerFixes(templateSpec map[string]interface{}, podSpec v1.PodSpec, fix func(c v1.Container) map[string]interface{}) {
+	for field, containers := range map[string][]v1.Container{"containers": podSpec.Containers, "initContainers": podSpec.InitContainers} {
+		fixes := []map[string]interface{}{}
+		for _, c := range containers {
+			if f := fix(c); f != nil {
+				fixes = append(fixes, f)
+			}
+		}
+		if len(fixes) > 0 {
+			templateSpec[field] = fixes
+		}
+	}
+}
+
+// autoFixOutcome turns the result of applying an auto-fix patch into an outcome that carries the patch.
+func autoFixOutcome(patch []byte, err error) ActionOutcome {
+	outcome := outcomeOf(err)
+	outcome.Detail = string(patch)
+	return outcome
+}
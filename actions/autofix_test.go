@@ -0,0 +1,79 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/k8guard/k8guardlibs/violations"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestAutoFixPatch(t *testing.T) {
+	privileged, unprivileged := true, false
+	hostPath := v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}
+	emptyDir := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+
+	tests := []struct {
+		name          string
+		podSpec       v1.PodSpec
+		violationType violations.ViolationType
+		want          string
+		wantErr       bool
+	}{
+		{
+			name: "privileged containers",
+			podSpec: v1.PodSpec{
+				InitContainers: []v1.Container{{Name: "setup", SecurityContext: &v1.SecurityContext{Privileged: &privileged}}},
+				Containers: []v1.Container{
+					{Name: "web", SecurityContext: &v1.SecurityContext{Privileged: &privileged}},
+					{Name: "sidecar", SecurityContext: &v1.SecurityContext{Privileged: &unprivileged}},
+					{Name: "plain"},
+				},
+			},
+			violationType: violations.PRIVILEGED_TYPE,
+			want:          `{"spec":{"template":{"spec":{"containers":[{"name":"web","securityContext":{"privileged":false}}],"initContainers":[{"name":"setup","securityContext":{"privileged":false}}]}}}}`,
+		},
+		{
+			name: "added capabilities",
+			podSpec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "web", SecurityContext: &v1.SecurityContext{Capabilities: &v1.Capabilities{Add: []v1.Capability{"NET_ADMIN"}}}},
+				{Name: "sidecar", SecurityContext: &v1.SecurityContext{Capabilities: &v1.Capabilities{Drop: []v1.Capability{"ALL"}}}},
+			}},
+			violationType: violations.CAPABILITIES_TYPE,
+			want:          `{"spec":{"template":{"spec":{"containers":[{"name":"web","securityContext":{"capabilities":{"add":null}}}]}}}}`,
+		},
+		{
+			name: "host volumes and their mounts",
+			podSpec: v1.PodSpec{
+				Volumes: []v1.Volume{{Name: "docker", VolumeSource: hostPath}, {Name: "scratch", VolumeSource: emptyDir}},
+				Containers: []v1.Container{
+					{Name: "web", VolumeMounts: []v1.VolumeMount{{Name: "docker", MountPath: "/var/run/docker.sock"}, {Name: "scratch", MountPath: "/tmp"}}},
+					{Name: "sidecar", VolumeMounts: []v1.VolumeMount{{Name: "scratch", MountPath: "/tmp"}}},
+				},
+			},
+			violationType: violations.HOST_VOLUMES_TYPE,
+			want:          `{"spec":{"template":{"spec":{"containers":[{"name":"web","volumeMounts":[{"$patch":"delete","mountPath":"/var/run/docker.sock"}]}],"volumes":[{"$patch":"delete","name":"docker"}]}}}}`,
+		},
+		{
+			name:          "nothing to fix",
+			podSpec:       v1.PodSpec{Containers: []v1.Container{{Name: "web", SecurityContext: &v1.SecurityContext{Privileged: &unprivileged}}}},
+			violationType: violations.PRIVILEGED_TYPE,
+			wantErr:       true,
+		},
+		{
+			name:          "not auto-fixable",
+			podSpec:       v1.PodSpec{Containers: []v1.Container{{Name: "web"}}},
+			violationType: violations.IMAGE_SIZE_TYPE,
+			wantErr:       true,
+		},
+	}
+	for _, test := range tests {
+		patch, err := autoFixPatch(test.podSpec, test.violationType)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: error %v, want an error %t", test.name, err, test.wantErr)
+			continue
+		}
+		if string(patch) != test.want {
+			t.Errorf("%s: patch\n%s\nwant\n%s", test.name, patch, test.want)
+		}
+	}
+}
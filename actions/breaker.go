@@ -0,0 +1,211 @@
+package actions
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"gopkg.in/gomail.v2"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Stops destructive actions once they exceed a ceiling, until somebody resets it.
+// The tripped state is kept in the db so a reset from the command line reaches the running process.
+type circuitBreaker struct {
+	mutex   sync.Mutex
+	tripped bool
+	reason  string
+	// destructive actions of the last hour
+	recent            []time.Time
+	recentByNamespace map[string][]time.Time
+	blocked           map[string]bool
+	// blocked actions that were not alerted about yet
+	unalerted int
+}
+
+var breaker = &circuitBreaker{recentByNamespace: map[string][]time.Time{}, blocked: map[string]bool{}}
+
+const breakerTemplate = `
+<img src="https://raw.githubusercontent.com/mpritter76/images/master/stop.png" style="width:20px;height:20px;">
+
+<b>The k8guard circuit breaker of {{.Cluster}} is tripped, no destructive actions are taken until it is reset.</b>
+<p>
+Reason: {{.Reason}}
+</p>
+<p>
+Blocked actions:
+<ul>
+{{range .Blocked}}<li>{{.}}</li>
+{{end}}</ul>
+Reset it with <code>k8guard-action reset-breaker -by &lt;you&gt;</code> once the actions are checked.
+</p>
+`
+
+// admit tells if a destructive action may run now, the breaker trips when the action would exceed a ceiling.
+// Only the actions that changed the entity are counted, see count.
+func (b *circuitBreaker) admit(namespace string, description string, now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	trippedNow := false
+	if !b.tripped {
+		b.recent = since(b.recent, now.Add(-time.Hour))
+		b.recentByNamespace[namespace] = since(b.recentByNamespace[namespace], now.Add(-time.Hour))
+
+		if reason := b.exceeded(namespace, now); len(reason) > 0 {
+			libs.Log.Error("Tripping the circuit breaker: ", reason)
+			b.tripped = true
+			b.reason = reason
+			trippedNow = true
+			db.TripBreakerRow(reason, now)
+		} else {
+			return true
+		}
+	}
+
+	libs.Log.Warn("Circuit breaker is tripped, blocked ", description)
+	if !b.blocked[description] {
+		b.blocked[description] = true
+		b.unalerted++
+		db.AddBreakerBlocked(description)
+	}
+	if trippedNow {
+		// later blocked actions are alerted by WatchCircuitBreaker
+		b.alert()
+	}
+	return false
+}
+
+// blocks tells if the action was already blocked since the breaker tripped,
+// a blocked action is logged and alerted once per trip.
+func (b *circuitBreaker) blocks(description string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.tripped && b.blocked[description]
+}
+
+// count counts a destructive action that changed the entity toward the ceilings.
+func (b *circuitBreaker) count(namespace string, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.recent = append(b.recent, now)
+	b.recentByNamespace[namespace] = append(b.recentByNamespace[namespace], now)
+}
+
+// exceeded returns which ceiling one more action would exceed, empty if none.
+func (b *circuitBreaker) exceeded(namespace string, now time.Time) string {
+	if config.Cfg.MaxActionsPerMinute > 0 && len(since(b.recent, now.Add(-time.Minute))) >= config.Cfg.MaxActionsPerMinute {
+		return fmt.Sprintf("more than %d destructive actions per minute", config.Cfg.MaxActionsPerMinute)
+	}
+	if config.Cfg.MaxActionsPerHour > 0 && len(b.recent) >= config.Cfg.MaxActionsPerHour {
+		return fmt.Sprintf("more than %d destructive actions per hour", config.Cfg.MaxActionsPerHour)
+	}
+	if config.Cfg.MaxActionsPerNamespace > 0 && len(b.recentByNamespace[namespace]) >= config.Cfg.MaxActionsPerNamespace {
+		return fmt.Sprintf("more than %d destructive actions per hour in namespace %s", config.Cfg.MaxActionsPerNamespace, namespace)
+	}
+	return ""
+}
+
+// sync takes over the state stored in the db, a reset clears the counted actions.
+func (b *circuitBreaker) sync() {
+	breakerRow := db.SelectBreakerRow()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.tripped && !breakerRow.Tripped() {
+		libs.Log.Info("Circuit breaker was reset by ", breakerRow.ResetBy, " at ", breakerRow.ResetAt)
+		b.recent = nil
+		b.recentByNamespace = map[string][]time.Time{}
+		b.blocked = map[string]bool{}
+		b.unalerted = 0
+	}
+	b.tripped = breakerRow.Tripped()
+	b.reason = breakerRow.Reason
+	for _, description := range breakerRow.Blocked {
+		b.blocked[description] = true
+	}
+
+	if b.tripped && b.unalerted > 0 {
+		b.alert()
+	}
+}
+
+// alert sends every blocked action to all the channels, the caller holds the lock.
+func (b *circuitBreaker) alert() {
+	blocked := []string{}
+	for description := range b.blocked {
+		blocked = append(blocked, description)
+	}
+	sort.Strings(blocked)
+	b.unalerted = 0
+
+	tmpl, err := template.New("breaker").Parse(breakerTemplate)
+	if err != nil {
+		panic(err)
+	}
+	var tpl bytes.Buffer
+	err = tmpl.Execute(&tpl, map[string]interface{}{"Cluster": libs.Cfg.ClusterName, "Reason": b.reason, "Blocked": blocked})
+	if err != nil {
+		panic(err)
+	}
+
+	// not routed by severity nor to the namespace owners, the operators need to know
+	go notifyHipChat(tpl.String(), &v1.Namespace{}, true)
+	go notifySlack(tpl.String(), libs.Cfg.SlackChannel, &v1.Namespace{}, true)
+	go notifyBreakerEmail(tpl.String())
+}
+
+func notifyBreakerEmail(message string) {
+	if len(libs.Cfg.SmtpServer) == 0 || len(libs.Cfg.SmtpFallbackSendTo) == 0 {
+		libs.Log.Debug("Skipping emailing the circuit breaker alert due to empty smtp server or fallback config")
+		return
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", libs.Cfg.SmtpSendFrom)
+	m.SetHeader("To", strings.Split(libs.Cfg.SmtpFallbackSendTo, ",")...)
+	m.SetHeader("X-Priority", "1")
+	m.SetHeader("Subject", "URGENT: k8guard circuit breaker tripped in "+libs.Cfg.ClusterName)
+	m.SetBody("text/html", message)
+	d := gomail.NewDialer(libs.Cfg.SmtpServer, libs.Cfg.SmtpPort, libs.Cfg.SmtpUsername, libs.Cfg.SmtpPassword)
+	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	if err := d.DialAndSend(m); err != nil {
+		libs.Log.Error(err)
+	}
+}
+
+// the times that are not before from
+func since(times []time.Time, from time.Time) []time.Time {
+	for i, t := range times {
+		if !t.Before(from) {
+			return times[i:]
+		}
+	}
+	return nil
+}
+
+// SyncCircuitBreaker loads the state of the circuit breaker from the db.
+func SyncCircuitBreaker() {
+	breaker.sync()
+}
+
+// WatchCircuitBreaker picks up resets and alerts about the actions blocked since the last alert.
+func WatchCircuitBreaker(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		breaker.sync()
+	}
+}
@@ -0,0 +1,235 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Decision of the Rego policy about a violation.
+type Decision string
+
+const (
+	// Escalate as the escalation policy says, also when the policy has no decision.
+	EscalateDecision Decision = "escalate"
+	// Notify but do not act, as in safe mode.
+	NotifyDecision Decision = "notify"
+	// Act now without the remaining warnings, safe mode, the scope and approvals still apply.
+	ActDecision Decision = "act"
+	// Neither notify nor act.
+	SkipDecision Decision = "skip"
+)
+
+type decisionResult struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// The Rego policy compiled from the files, nil without one. It is replaced when the files change.
+type regoPolicy struct {
+	mutex    sync.RWMutex
+	compiler *ast.Compiler
+	// modification time of each file the policy was compiled from
+	files map[string]time.Time
+}
+
+var decisionPolicy = &regoPolicy{}
+
+// LoadDecisionPolicy compiles the .rego files, path is a file or a directory of them.
+func LoadDecisionPolicy(path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	files, err := regoFiles(path)
+	if err != nil {
+		return err
+	}
+	return decisionPolicy.load(files)
+}
+
+// WatchDecisionPolicy compiles the .rego files again when they change, a policy that does not compile
+// is logged and the previous one is kept.
+func WatchDecisionPolicy(path string, interval time.Duration) {
+	if len(path) == 0 {
+		return
+	}
+	for {
+		time.Sleep(interval)
+		files, err := regoFiles(path)
+		if err != nil {
+			libs.Log.Error("Could not read the decision policy ", path, ": ", err)
+			continue
+		}
+		if decisionPolicy.changed(files) {
+			if err := decisionPolicy.load(files); err != nil {
+				libs.Log.Error("Keeping the previous decision policy: ", err)
+			}
+		}
+	}
+}
+
+// regoFiles returns the .rego files of the path with their modification times.
+func regoFiles(path string) (map[string]time.Time, error) {
+	files := map[string]time.Time{}
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(file, ".rego") {
+			files[file] = info.ModTime()
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (p *regoPolicy) changed(files map[string]time.Time) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if len(files) != len(p.files) {
+		return true
+	}
+	for file, modTime := range files {
+		if !p.files[file].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *regoPolicy) load(files map[string]time.Time) error {
+	modules := map[string]*ast.Module{}
+	for file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		module, err := ast.ParseModule(file, string(content))
+		if err != nil {
+			return fmt.Errorf("Invalid decision policy %s: %v", file, err)
+		}
+		modules[file] = module
+	}
+
+	compiler := ast.NewCompiler()
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return fmt.Errorf("Invalid decision policy: %v", compiler.Errors)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.compiler = compiler
+	p.files = files
+
+	names := []string{}
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+	libs.Log.Info("Loaded the decision policy from ", strings.Join(names, ", "))
+	return nil
+}
+
+// decide evaluates the decision query with the entity, the violation, the namespace and what was done so far.
+// Without a policy, or when it has no decision or fails, the violation escalates as usual.
+func decide(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, violationSource string, violationType violations.ViolationType, policy escalation) decisionResult {
+	decisionPolicy.mutex.RLock()
+	compiler := decisionPolicy.compiler
+	decisionPolicy.mutex.RUnlock()
+	if compiler == nil {
+		return decisionResult{Decision: EscalateDecision}
+	}
+
+	input, err := decisionInput(entity, vEntity, lastActions, violationSource, violationType, policy)
+	if err != nil {
+		libs.Log.Error("Escalating as usual, the decision policy input could not be built: ", err)
+		return decisionResult{Decision: EscalateDecision}
+	}
+
+	rs, err := rego.New(rego.Query(config.Cfg.DecisionQuery), rego.Compiler(compiler), rego.Input(input)).Eval(context.Background())
+	if err != nil {
+		libs.Log.Error("Escalating as usual, the decision policy failed: ", err)
+		return decisionResult{Decision: EscalateDecision}
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return decisionResult{Decision: EscalateDecision}
+	}
+
+	// the value is plain JSON, decoded into the result
+	result := decisionResult{}
+	value, err := json.Marshal(rs[0].Expressions[0].Value)
+	if err == nil {
+		err = json.Unmarshal(value, &result)
+	}
+	if err != nil {
+		libs.Log.Error("Escalating as usual, the decision policy returned ", rs[0].Expressions[0].Value, ": ", err)
+		return decisionResult{Decision: EscalateDecision}
+	}
+	switch result.Decision {
+	case EscalateDecision, NotifyDecision, ActDecision, SkipDecision:
+	case "":
+		result.Decision = EscalateDecision
+	default:
+		libs.Log.Error("Escalating as usual, the decision policy returned the unknown decision ", result.Decision)
+		return decisionResult{Decision: EscalateDecision}
+	}
+	return result
+}
+
+// decisionInput is the input document of the policy, as JSON types.
+func decisionInput(entity ActionableEntity, vEntity libs.ViolatableEntity, lastActions map[string][]time.Time, violationSource string, violationType violations.ViolationType, policy escalation) (interface{}, error) {
+	namespace := map[string]interface{}{"name": vEntity.Namespace}
+	if ns, err := kube.Cache().Namespace(vEntity.Namespace); err == nil {
+		namespace["labels"] = ns.Labels
+		namespace["annotations"] = ns.Annotations
+	}
+
+	document := map[string]interface{}{
+		"entity": map[string]interface{}{
+			"kind":      strings.TrimPrefix(reflect.TypeOf(entity).Name(), "Action"),
+			"name":      vEntity.Name,
+			"namespace": vEntity.Namespace,
+			"spec":      entity,
+		},
+		"violation": map[string]interface{}{
+			"type":     violationType,
+			"source":   violationSource,
+			"severity": policy.Severity,
+		},
+		"namespace":   namespace,
+		"lastActions": lastActions,
+		"escalation": map[string]interface{}{
+			"warningCount":   policy.WarningCount,
+			"notifyInterval": policy.NotifyInterval.String(),
+			"action":         remediationFor(entity, vEntity.Namespace, violationType),
+			"allowed":        policy.Allowed,
+			"safeMode":       policy.SafeMode,
+			"offenses":       len(policy.Offenses),
+		},
+	}
+
+	content, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var input interface{}
+	err = json.Unmarshal(content, &input)
+	return input, err
+}
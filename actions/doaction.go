@@ -8,20 +8,14 @@ import (
 )
 
 //  actionable is interface, violatable is struct
-func DoAction(action Action, entity ActionableEntity, violatableEntity libs.ViolatableEntity, lastActions map[string][]time.Time, dryRun bool) map[string][]time.Time {
-	doneActions := map[string][]time.Time{}
-
+func DoAction(action Action, entity ActionableEntity, violatableEntity libs.ViolatableEntity, lastActions map[string][]time.Time, state ViolationState, dryRun bool, scope Scope) []DoneAction {
 	if dryRun {
-		libs.Log.Info("Running dry run for action ", reflect.TypeOf(action).Name())
-		return doneActions
+		libs.Log.Info("Running action ", reflect.TypeOf(action).Name(), " in shadow mode")
 	}
 
-	for _, doneAction := range action.DoAction(entity, violatableEntity, lastActions) {
-		if _, ok := doneActions[doneAction]; ok {
-			doneActions[doneAction] = append(doneActions[doneAction], time.Now())
-		} else {
-			doneActions[doneAction] = []time.Time{time.Now()}
-		}
+	doneActions := action.DoAction(entity, violatableEntity, lastActions, state, dryRun, scope)
+	for i := range doneActions {
+		doneActions[i].At = time.Now()
 	}
 
 	return doneActions
@@ -1,13 +1,28 @@
 package actions
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k8guard/k8guard-action/kube"
+
 	libs "github.com/k8guard/k8guardlibs"
-	"github.com/k8guard/k8guardlibs/k8s"
+	"github.com/k8guard/k8guardlibs/violations"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type ActionableEntity interface {
-	DoAction()
+	DoAction(remediation Remediation, violation violations.Violation) ActionOutcome
+}
+
+// Entities whose reversible remediations can be undone.
+type ReversibleEntity interface {
+	// The state a reversible remediation is going to change.
+	CurrentState() (map[string]string, error)
+	// Puts back a state returned by CurrentState.
+	Restore(state map[string]string) error
 }
 
 // See http://stackoverflow.com/questions/28800672/how-to-add-new-methods-to-an-existing-type-in-go
@@ -19,106 +34,494 @@ type ActionIngress libs.Ingress
 type ActionJob libs.Job
 type ActionCronJob libs.CronJob
 
-func (a ActionPod) DoAction() {
-	clientset, err := k8s.LoadClientset()
+// k8guardlibs does not model these kinds, their messages carry the common violatable fields only.
+type ActionStatefulSet struct {
+	libs.ViolatableEntity
+}
+type ActionReplicaSet struct {
+	libs.ViolatableEntity
+}
+type ActionReplicationController struct {
+	libs.ViolatableEntity
+}
+
+func (a ActionPod) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		panic(err)
+		return outcomeOf(err)
+	}
+	switch remediation {
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting Pod ", a.Name, " in namespace ", a.Namespace)
+		err = clientset.CoreV1().Pods(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching Pod ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.CoreV1().Pods(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, false))
+	default:
+		return unsupportedOutcome(remediation, "Pod")
 	}
-	libs.Log.Debug("Deleting Pod ", a.Name, " in namespace ", a.Namespace)
-	err = clientset.CoreV1().Pods(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	return outcomeOf(err)
+}
+
+func (a ActionDeployment) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		libs.Log.Error(err)
+		return outcomeOf(err)
 	}
+	switch remediation {
+	case ScaleToZeroRemediation:
+		libs.Log.Debug("Scaling Deployment ", a.Name, " in namespace ", a.Namespace)
+		kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		replicas := int32(0)
+		kd.Spec.Replicas = &replicas
+		_, err = clientset.AppsV1beta1().Deployments(a.Namespace).Update(kd)
+		return outcomeOf(err)
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting Deployment ", a.Name, " in namespace ", a.Namespace)
+		propagation := metav1.DeletePropagationBackground
+		err = clientset.AppsV1beta1().Deployments(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching Deployment ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.AppsV1beta1().Deployments(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, true))
+	case AutoFixRemediation:
+		kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		patch, err := autoFixPatch(kd.Spec.Template.Spec, violation.Type)
+		if err != nil {
+			return ActionOutcome{Status: SkippedStatus, Err: err}
+		}
+		libs.Log.Debug("Fixing Deployment ", a.Name, " in namespace ", a.Namespace, " with ", string(patch))
+		_, err = clientset.AppsV1beta1().Deployments(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, patch)
+		return autoFixOutcome(patch, err)
+	default:
+		return unsupportedOutcome(remediation, "Deployment")
+	}
+	return outcomeOf(err)
 }
 
-func (a ActionDeployment) DoAction() {
-	clientset, err := k8s.LoadClientset()
+func (a ActionNamespace) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		panic(err)
+		return outcomeOf(err)
 	}
-	libs.Log.Debug("Scaling Deployment ", a.Name, " in namespace ", a.Namespace)
-	kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	switch remediation {
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting Namespace ", a.Name)
+		err = clientset.CoreV1().Namespaces().Delete(a.Name, &metav1.DeleteOptions{})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching Namespace ", a.Name, " for ", remediation)
+		_, err = clientset.CoreV1().Namespaces().Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, false))
+	case FreezeRemediation:
+		err = a.freezeNamespace(clientset)
+	default:
+		return unsupportedOutcome(remediation, "Namespace")
+	}
+	return outcomeOf(err)
+}
+
+func (a ActionDaemonSet) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		libs.Log.Error(err)
-		return
+		return outcomeOf(err)
 	}
-	replicas := int32(0)
-	kd.Spec.Replicas = &replicas
-	_, err = clientset.AppsV1beta1().Deployments(a.Namespace).Update(kd)
+	switch remediation {
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting DaemonSet ", a.Name, " in namespace ", a.Namespace)
+		err = clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching DaemonSet ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, true))
+	case AutoFixRemediation:
+		kds, err := clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		patch, err := autoFixPatch(kds.Spec.Template.Spec, violation.Type)
+		if err != nil {
+			return ActionOutcome{Status: SkippedStatus, Err: err}
+		}
+		libs.Log.Debug("Fixing DaemonSet ", a.Name, " in namespace ", a.Namespace, " with ", string(patch))
+		_, err = clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, patch)
+		return autoFixOutcome(patch, err)
+	default:
+		return unsupportedOutcome(remediation, "DaemonSet")
+	}
+	return outcomeOf(err)
+}
+
+func (a ActionIngress) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		libs.Log.Error(err)
+		return outcomeOf(err)
 	}
+	switch remediation {
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting Ingress ", a.Name, " in namespace ", a.Namespace)
+		err = clientset.ExtensionsV1beta1().Ingresses(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching Ingress ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.ExtensionsV1beta1().Ingresses(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, false))
+	default:
+		return unsupportedOutcome(remediation, "Ingress")
+	}
+	return outcomeOf(err)
 }
 
-func (a ActionNamespace) DoAction() {
-	clientset, err := k8s.LoadClientset()
+func (a ActionJob) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return outcomeOf(err)
+	}
+	switch remediation {
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting Job ", a.Name, " in namespace ", a.Namespace)
+		err = clientset.BatchV1().Jobs(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching Job ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.BatchV1().Jobs(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, false))
+	default:
+		return unsupportedOutcome(remediation, "Job")
+	}
+	return outcomeOf(err)
+}
+
+func (a ActionCronJob) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	if libs.Cfg.IncludeAlpha == false {
+		libs.Log.Debug("Ignoring CronJob action as alpha features are not enabled ")
+		return ActionOutcome{Status: SkippedStatus}
+	}
+
+	clientset, err := kube.Clientset()
 	if err != nil {
-		panic(err)
+		return outcomeOf(err)
 	}
-	libs.Log.Debug("Deleting Namespace ", a.Name)
-	err = clientset.Namespaces().Delete(a.Name, &metav1.DeleteOptions{})
+	switch remediation {
+	case SuspendRemediation:
+		libs.Log.Debug("Disabling CronJob ", a.Name, " in namespace ", a.Namespace)
+
+		kcj, err := clientset.BatchV2alpha1().CronJobs(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		suspend := true
+		kcj.Spec.Suspend = &suspend
+		_, err = clientset.BatchV2alpha1().CronJobs(a.Namespace).Update(kcj)
+		return outcomeOf(err)
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting CronJob ", a.Name, " in namespace ", a.Namespace)
+		propagation := metav1.DeletePropagationBackground
+		err = clientset.BatchV2alpha1().CronJobs(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching CronJob ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.BatchV2alpha1().CronJobs(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, false))
+	default:
+		return unsupportedOutcome(remediation, "CronJob")
+	}
+	return outcomeOf(err)
+}
+
+func (a ActionStatefulSet) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		libs.Log.Error(err)
+		return outcomeOf(err)
 	}
+	switch remediation {
+	case ScaleToZeroRemediation:
+		libs.Log.Debug("Scaling StatefulSet ", a.Name, " in namespace ", a.Namespace)
+		kss, err := clientset.AppsV1beta1().StatefulSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		replicas := int32(0)
+		kss.Spec.Replicas = &replicas
+		_, err = clientset.AppsV1beta1().StatefulSets(a.Namespace).Update(kss)
+		return outcomeOf(err)
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting StatefulSet ", a.Name, " in namespace ", a.Namespace)
+		propagation := metav1.DeletePropagationBackground
+		err = clientset.AppsV1beta1().StatefulSets(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching StatefulSet ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.AppsV1beta1().StatefulSets(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, true))
+	default:
+		return unsupportedOutcome(remediation, "StatefulSet")
+	}
+	return outcomeOf(err)
 }
 
-func (a ActionDaemonSet) DoAction() {
-	clientset, err := k8s.LoadClientset()
+func (a ActionReplicaSet) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		panic(err)
+		return outcomeOf(err)
+	}
+	switch remediation {
+	case ScaleToZeroRemediation:
+		libs.Log.Debug("Scaling ReplicaSet ", a.Name, " in namespace ", a.Namespace)
+		krs, err := clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		replicas := int32(0)
+		krs.Spec.Replicas = &replicas
+		_, err = clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Update(krs)
+		return outcomeOf(err)
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting ReplicaSet ", a.Name, " in namespace ", a.Namespace)
+		propagation := metav1.DeletePropagationBackground
+		err = clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching ReplicaSet ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, true))
+	default:
+		return unsupportedOutcome(remediation, "ReplicaSet")
 	}
-	libs.Log.Debug("Deleting DaemonSet ", a.Name, " in namespace ", a.Namespace)
-	err = clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	return outcomeOf(err)
+}
+
+func (a ActionReplicationController) DoAction(remediation Remediation, violation violations.Violation) ActionOutcome {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		libs.Log.Error(err)
+		return outcomeOf(err)
 	}
+	switch remediation {
+	case ScaleToZeroRemediation:
+		libs.Log.Debug("Scaling ReplicationController ", a.Name, " in namespace ", a.Namespace)
+		krc, err := clientset.CoreV1().ReplicationControllers(a.Namespace).Get(a.Name, metav1.GetOptions{})
+		if err != nil {
+			return outcomeOf(err)
+		}
+		replicas := int32(0)
+		krc.Spec.Replicas = &replicas
+		_, err = clientset.CoreV1().ReplicationControllers(a.Namespace).Update(krc)
+		return outcomeOf(err)
+	case DeleteRemediation:
+		libs.Log.Debug("Deleting ReplicationController ", a.Name, " in namespace ", a.Namespace)
+		propagation := metav1.DeletePropagationBackground
+		err = clientset.CoreV1().ReplicationControllers(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	case AnnotateRemediation, LabelIsolateRemediation:
+		libs.Log.Debug("Patching ReplicationController ", a.Name, " in namespace ", a.Namespace, " for ", remediation)
+		_, err = clientset.CoreV1().ReplicationControllers(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, remediationPatch(remediation, violation, true))
+	default:
+		return unsupportedOutcome(remediation, "ReplicationController")
+	}
+	return outcomeOf(err)
 }
 
-func (a ActionIngress) DoAction() {
-	clientset, err := k8s.LoadClientset()
+func (a ActionDeployment) CurrentState() (map[string]string, error) {
+	clientset, err := kube.Clientset()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	libs.Log.Debug("Deleting Ingress ", a.Name, " in namespace ", a.Namespace)
-	err = clientset.Ingresses(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
 	if err != nil {
-		libs.Log.Error(err)
+		return nil, err
 	}
+	return replicasState(kd.Spec.Replicas), nil
 }
 
-func (a ActionJob) DoAction() {
-	clientset, err := k8s.LoadClientset()
+func (a ActionDeployment) Restore(state map[string]string) error {
+	replicas, err := restoredReplicas(state, "Deployment", a.Name)
+	if err != nil {
+		return err
+	}
+	clientset, err := kube.Clientset()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	libs.Log.Debug("Deleting Job ", a.Name, " in namespace ", a.Namespace)
-	err = clientset.BatchV1().Jobs(a.Namespace).Delete(a.Name, &metav1.DeleteOptions{})
+	libs.Log.Debug("Restoring Deployment ", a.Name, " in namespace ", a.Namespace, " to ", replicas, " replicas")
+	kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
 	if err != nil {
-		libs.Log.Error(err)
+		return err
 	}
+	kd.Spec.Replicas = &replicas
+	_, err = clientset.AppsV1beta1().Deployments(a.Namespace).Update(kd)
+	return err
 }
 
-func (a ActionCronJob) DoAction() {
+func (a ActionCronJob) CurrentState() (map[string]string, error) {
 	if libs.Cfg.IncludeAlpha == false {
-		libs.Log.Debug("Ignoring CronJob action as alpha features are not enabled ")
-		return
+		return nil, fmt.Errorf("CronJob %s is ignored as alpha features are not enabled", a.Name)
 	}
-
-	clientset, err := k8s.LoadClientset()
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kcj, err := clientset.BatchV2alpha1().CronJobs(a.Namespace).Get(a.Name, metav1.GetOptions{})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	libs.Log.Debug("Disabling CronJob ", a.Name, " in namespace ", a.Namespace)
+	suspend := false
+	if kcj.Spec.Suspend != nil {
+		suspend = *kcj.Spec.Suspend
+	}
+	return map[string]string{"suspend": strconv.FormatBool(suspend)}, nil
+}
 
+func (a ActionCronJob) Restore(state map[string]string) error {
+	if libs.Cfg.IncludeAlpha == false {
+		return fmt.Errorf("CronJob %s is ignored as alpha features are not enabled", a.Name)
+	}
+	suspend, err := strconv.ParseBool(state["suspend"])
+	if err != nil {
+		return fmt.Errorf("Invalid recorded suspend %q for CronJob %s", state["suspend"], a.Name)
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	libs.Log.Debug("Restoring CronJob ", a.Name, " in namespace ", a.Namespace, " to suspend ", suspend)
 	kcj, err := clientset.BatchV2alpha1().CronJobs(a.Namespace).Get(a.Name, metav1.GetOptions{})
 	if err != nil {
-		libs.Log.Error(err)
-		return
+		return err
 	}
-	suspend := true
 	kcj.Spec.Suspend = &suspend
 	_, err = clientset.BatchV2alpha1().CronJobs(a.Namespace).Update(kcj)
+	return err
+}
+
+func (a ActionStatefulSet) CurrentState() (map[string]string, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kss, err := clientset.AppsV1beta1().StatefulSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return replicasState(kss.Spec.Replicas), nil
+}
+
+func (a ActionStatefulSet) Restore(state map[string]string) error {
+	replicas, err := restoredReplicas(state, "StatefulSet", a.Name)
+	if err != nil {
+		return err
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	libs.Log.Debug("Restoring StatefulSet ", a.Name, " in namespace ", a.Namespace, " to ", replicas, " replicas")
+	kss, err := clientset.AppsV1beta1().StatefulSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	kss.Spec.Replicas = &replicas
+	_, err = clientset.AppsV1beta1().StatefulSets(a.Namespace).Update(kss)
+	return err
+}
+
+func (a ActionReplicaSet) CurrentState() (map[string]string, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	krs, err := clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
 	if err != nil {
-		libs.Log.Error(err)
+		return nil, err
+	}
+	return replicasState(krs.Spec.Replicas), nil
+}
+
+func (a ActionReplicaSet) Restore(state map[string]string) error {
+	replicas, err := restoredReplicas(state, "ReplicaSet", a.Name)
+	if err != nil {
+		return err
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	libs.Log.Debug("Restoring ReplicaSet ", a.Name, " in namespace ", a.Namespace, " to ", replicas, " replicas")
+	krs, err := clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	krs.Spec.Replicas = &replicas
+	_, err = clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Update(krs)
+	return err
+}
+
+func (a ActionReplicationController) CurrentState() (map[string]string, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	krc, err := clientset.CoreV1().ReplicationControllers(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return replicasState(krc.Spec.Replicas), nil
+}
+
+func (a ActionReplicationController) Restore(state map[string]string) error {
+	replicas, err := restoredReplicas(state, "ReplicationController", a.Name)
+	if err != nil {
+		return err
+	}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	libs.Log.Debug("Restoring ReplicationController ", a.Name, " in namespace ", a.Namespace, " to ", replicas, " replicas")
+	krc, err := clientset.CoreV1().ReplicationControllers(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	krc.Spec.Replicas = &replicas
+	_, err = clientset.CoreV1().ReplicationControllers(a.Namespace).Update(krc)
+	return err
+}
+
+// replicas default to 1 when they are not set
+func replicasState(replicas *int32) map[string]string {
+	r := int32(1)
+	if replicas != nil {
+		r = *replicas
+	}
+	return map[string]string{"replicas": strconv.Itoa(int(r))}
+}
+
+func restoredReplicas(state map[string]string, kind string, name string) (int32, error) {
+	replicas, err := strconv.Atoi(state["replicas"])
+	if err != nil {
+		return 0, fmt.Errorf("Invalid recorded replicas %q for %s %s", state["replicas"], kind, name)
+	}
+	return int32(replicas), nil
+}
+
+// newActionableEntity builds an entity of the given kind (e.g. Deployment or ActionDeployment) that is only identified by name.
+func newActionableEntity(kind string, namespace string, name string) (ActionableEntity, error) {
+	return newActionableEntityFor(kind, libs.ViolatableEntity{Name: name, Namespace: namespace})
+}
+
+func newActionableEntityFor(kind string, vEntity libs.ViolatableEntity) (ActionableEntity, error) {
+	switch "Action" + strings.TrimPrefix(kind, "Action") {
+	case "ActionPod":
+		return ActionPod{ViolatableEntity: vEntity}, nil
+	case "ActionNamespace":
+		return ActionNamespace{ViolatableEntity: vEntity}, nil
+	case "ActionDeployment":
+		return ActionDeployment{ViolatableEntity: vEntity}, nil
+	case "ActionDaemonSet":
+		return ActionDaemonSet{ViolatableEntity: vEntity}, nil
+	case "ActionIngress":
+		return ActionIngress{ViolatableEntity: vEntity}, nil
+	case "ActionJob":
+		return ActionJob{ViolatableEntity: vEntity}, nil
+	case "ActionCronJob":
+		return ActionCronJob{ViolatableEntity: vEntity}, nil
+	case "ActionStatefulSet":
+		return ActionStatefulSet{ViolatableEntity: vEntity}, nil
+	case "ActionReplicaSet":
+		return ActionReplicaSet{ViolatableEntity: vEntity}, nil
+	case "ActionReplicationController":
+		return ActionReplicationController{ViolatableEntity: vEntity}, nil
+	default:
+		return nil, fmt.Errorf("Unknown Actionable Entity Kind %s", kind)
 	}
 }
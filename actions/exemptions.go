@@ -0,0 +1,52 @@
+package actions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+// the exemptions of the exempt command, read from the db every interval instead of for every violation
+var commandExemptions = struct {
+	sync.RWMutex
+	rows []db.ExemptionRow
+}{rows: []db.ExemptionRow{}}
+
+// WatchExemptions picks up the exemptions added and removed with the exempt command every interval.
+func WatchExemptions(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		SyncExemptions()
+	}
+}
+
+// SyncExemptions reads the exemptions that did not expire yet.
+func SyncExemptions() {
+	rows := db.SelectExemptionRows()
+
+	commandExemptions.Lock()
+	defer commandExemptions.Unlock()
+	if len(rows) != len(commandExemptions.rows) {
+		libs.Log.Info("Applying ", len(rows), " exemptions")
+	}
+	commandExemptions.rows = rows
+}
+
+// ExemptionOf returns the first exemption of the exempt command that matches the violation and did not expire.
+func ExemptionOf(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) (db.ExemptionRow, bool) {
+	commandExemptions.RLock()
+	exemptions := commandExemptions.rows
+	commandExemptions.RUnlock()
+
+	now := time.Now()
+	for _, exemption := range exemptions {
+		if now.Before(exemption.ExpiresAt) && exemption.Matches(vEntity, violation, entityType) {
+			return exemption, true
+		}
+	}
+	return db.ExemptionRow{}, false
+}
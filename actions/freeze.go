@@ -0,0 +1,152 @@
+package actions
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const freezeQuotaName = "k8guard-freeze"
+
+// freezeNamespace stops new pods from being created in the namespace with a zero pod resource quota
+// and, when configured, scales its Deployments and StatefulSets to zero.
+func (a ActionNamespace) freezeNamespace(clientset kubernetes.Interface) error {
+	quota := &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   freezeQuotaName,
+			Labels: map[string]string{ownerLabel: "k8guard"},
+		},
+		Spec: v1.ResourceQuotaSpec{
+			Hard: v1.ResourceList{v1.ResourcePods: resource.MustParse("0")},
+		},
+	}
+	libs.Log.Debug("Freezing Namespace ", a.Name)
+	_, err := clientset.CoreV1().ResourceQuotas(a.Name).Create(quota)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	if config.Cfg.FreezeScalesWorkloads == false {
+		return nil
+	}
+
+	zero := int32(0)
+	deployments, err := clientset.AppsV1beta1().Deployments(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, kd := range deployments.Items {
+		libs.Log.Debug("Scaling Deployment ", kd.Name, " in frozen namespace ", a.Name, " to zero")
+		kd.Spec.Replicas = &zero
+		_, err = clientset.AppsV1beta1().Deployments(a.Name).Update(&kd)
+		if err != nil {
+			return err
+		}
+	}
+	statefulSets, err := clientset.AppsV1beta1().StatefulSets(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, kss := range statefulSets.Items {
+		libs.Log.Debug("Scaling StatefulSet ", kss.Name, " in frozen namespace ", a.Name, " to zero")
+		kss.Spec.Replicas = &zero
+		_, err = clientset.AppsV1beta1().StatefulSets(a.Name).Update(&kss)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CurrentState records the replicas of the workloads a freeze scales down, keyed by Kind/name.
+func (a ActionNamespace) CurrentState() (map[string]string, error) {
+	state := map[string]string{"quota": freezeQuotaName}
+	if config.Cfg.FreezeScalesWorkloads == false {
+		return state, nil
+	}
+
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := clientset.AppsV1beta1().Deployments(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kd := range deployments.Items {
+		state["Deployment/"+kd.Name] = replicasState(kd.Spec.Replicas)["replicas"]
+	}
+	statefulSets, err := clientset.AppsV1beta1().StatefulSets(a.Name).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, kss := range statefulSets.Items {
+		state["StatefulSet/"+kss.Name] = replicasState(kss.Spec.Replicas)["replicas"]
+	}
+	return state, nil
+}
+
+// Restore unfreezes the namespace, it removes the resource quota and scales the workloads back.
+func (a ActionNamespace) Restore(state map[string]string) error {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+
+	libs.Log.Debug("Unfreezing Namespace ", a.Name)
+	err = clientset.CoreV1().ResourceQuotas(a.Name).Delete(freezeQuotaName, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	problems := []string{}
+	for key, value := range state {
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		replicas, err := strconv.Atoi(value)
+		if err != nil {
+			problems = append(problems, "invalid recorded replicas "+value+" for "+key)
+			continue
+		}
+		r := int32(replicas)
+
+		libs.Log.Debug("Restoring ", key, " in namespace ", a.Name, " to ", r, " replicas")
+		switch parts[0] {
+		case "Deployment":
+			kd, err := clientset.AppsV1beta1().Deployments(a.Name).Get(parts[1], metav1.GetOptions{})
+			if err == nil {
+				kd.Spec.Replicas = &r
+				_, err = clientset.AppsV1beta1().Deployments(a.Name).Update(kd)
+			}
+			if err != nil {
+				problems = append(problems, key+": "+err.Error())
+			}
+		case "StatefulSet":
+			kss, err := clientset.AppsV1beta1().StatefulSets(a.Name).Get(parts[1], metav1.GetOptions{})
+			if err == nil {
+				kss.Spec.Replicas = &r
+				_, err = clientset.AppsV1beta1().StatefulSets(a.Name).Update(kss)
+			}
+			if err != nil {
+				problems = append(problems, key+": "+err.Error())
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Namespace " + a.Name + " was only partly unfrozen: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package actions
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// gracePeriodEnd returns when the grace period of a newly created entity ends,
+// inGrace is false when there is no grace period or it is over.
+func gracePeriodEnd(entity ActionableEntity, now time.Time) (time.Time, bool) {
+	if config.Cfg.GracePeriod <= 0 {
+		return time.Time{}, false
+	}
+
+	meta, err := objectMetaOf(entity)
+	if err != nil {
+		libs.Log.Warn("Not applying the grace period to ", reflect.TypeOf(entity).Name(), " as its creation time could not be read: ", err)
+		return time.Time{}, false
+	}
+
+	end := meta.CreationTimestamp.Add(config.Cfg.GracePeriod)
+	return end, now.Before(end)
+}
@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestGracePeriodEnd(t *testing.T) {
+	previousCache, previousGracePeriod := kube.Cache(), config.Cfg.GracePeriod
+	defer func() {
+		kube.SetCache(previousCache)
+		config.Cfg.GracePeriod = previousGracePeriod
+	}()
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	fake := kube.NewFakeCache()
+	fake.Ingresses["team/web"] = &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "web", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))}}
+	kube.SetCache(fake)
+
+	ingress := func(name string) ActionableEntity {
+		return ActionIngress{ViolatableEntity: libs.ViolatableEntity{Namespace: "team", Name: name}}
+	}
+	tests := []struct {
+		entity      ActionableEntity
+		gracePeriod time.Duration
+		wantEnd     time.Time
+		wantInGrace bool
+	}{
+		{ingress("web"), 0, time.Time{}, false},
+		{ingress("web"), 2 * time.Hour, now.Add(time.Hour), true},
+		{ingress("web"), time.Hour, now, false},
+		{ingress("web"), 30 * time.Minute, now.Add(-30 * time.Minute), false},
+		// not in the cache, no grace period
+		{ingress("gone"), 2 * time.Hour, time.Time{}, false},
+	}
+	for _, test := range tests {
+		config.Cfg.GracePeriod = test.gracePeriod
+		end, inGrace := gracePeriodEnd(test.entity, now)
+		if !end.Equal(test.wantEnd) || inGrace != test.wantInGrace {
+			t.Errorf("gracePeriodEnd(%v) with a %s grace period = %s, %t, want %s, %t", test.entity, test.gracePeriod, end, inGrace, test.wantEnd, test.wantInGrace)
+		}
+	}
+}
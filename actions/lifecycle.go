@@ -0,0 +1,237 @@
+package actions
+
+import (
+	"strings"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+// ViolationState is where a violation is in its lifecycle, kept with the violation in vaction.
+// Every change is recorded as a transition in vtransition, except in shadow mode.
+type ViolationState string
+
+const (
+	// Reported but nobody was warned yet, e.g. in the grace period or after a restore.
+	ObservedState ViolationState = "observed"
+	WarnedState   ViolationState = "warned"
+	// The next notification comes with the action.
+	LastWarningState ViolationState = "last-warning"
+	// The action is due but waits for an approval or the next enforcement window.
+	PendingActionState ViolationState = "pending-action"
+	ActionedState      ViolationState = "actioned"
+	// No longer reported, its row expired after DurationViolationExpires.
+	ResolvedState ViolationState = "resolved"
+	// It matches an exemption.
+	ExemptedState ViolationState = "exempted"
+)
+
+// Triggers of the transitions that no done action causes.
+const (
+	expiryTrigger    = "expiry"
+	exemptionTrigger = "exemption"
+	migrationTrigger = "migration"
+)
+
+// The states each state can move to, a violation without a state is new. Any other transition is refused
+// and the violation stays in its state.
+var violationTransitions = map[ViolationState][]ViolationState{
+	"":                 {ObservedState, WarnedState, LastWarningState, PendingActionState, ActionedState, ExemptedState},
+	ObservedState:      {WarnedState, LastWarningState, PendingActionState, ActionedState, ExemptedState, ResolvedState},
+	WarnedState:        {ObservedState, LastWarningState, PendingActionState, ActionedState, ExemptedState, ResolvedState},
+	LastWarningState:   {ObservedState, PendingActionState, ActionedState, ExemptedState, ResolvedState},
+	PendingActionState: {ObservedState, ActionedState, ExemptedState, ResolvedState},
+	ActionedState:      {ObservedState, PendingActionState, ExemptedState, ResolvedState},
+	ExemptedState:      {ObservedState, WarnedState, LastWarningState, PendingActionState, ActionedState, ResolvedState},
+	ResolvedState:      {ObservedState, WarnedState, LastWarningState, PendingActionState, ActionedState, ExemptedState},
+}
+
+// actionedState is the state after an entity action, only a taken action moves the violation.
+func actionedState(status ActionStatus) ViolationState {
+	if status == SuccessStatus || status == ShadowStatus {
+		return ActionedState
+	}
+	return ""
+}
+
+// migratedState is the state the action history of a violation tracked before states existed implies,
+// the latest action decides. It can not tell a last warning from a warning.
+func migratedState(actions map[string][]time.Time) string {
+	state := ObservedState
+	latest := time.Time{}
+	for action, implied := range map[string]ViolationState{
+		NotifyGraceActionName:    ObservedState,
+		NotifyActionName:         WarnedState,
+		EntityActionDeferredName: PendingActionState,
+		EntityActionPendingName:  PendingActionState,
+		EntityActionName:         ActionedState,
+	} {
+		if t := actions[action]; len(t) > 0 && t[len(t)-1].After(latest) {
+			latest = t[len(t)-1]
+			state = implied
+		}
+	}
+	return string(state)
+}
+
+// MigrateViolationStates gives the violations tracked before states existed their state, see db.MigrateVactionStates.
+func MigrateViolationStates() int {
+	return db.MigrateVactionStates(migratedState, string(ResolvedState), migrationTrigger)
+}
+
+// CurrentState returns the state of the violation row and since when it is in it. A row whose predecessor expired
+// is resolved and a row without a state is migrated, both are recorded as transitions unless in shadow mode.
+func CurrentState(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, row db.VActionRow, dryRun bool) (ViolationState, time.Time) {
+	if !row.ExpiredAt.IsZero() {
+		if ViolationState(row.ExpiredState) != ResolvedState && !dryRun {
+			recordTransition(vEntity, violation, entityType, ViolationState(row.ExpiredState), ResolvedState, expiryTrigger, row.ExpiredAt)
+		}
+		return ResolvedState, row.ExpiredAt
+	}
+	if len(row.State) == 0 && len(row.Actions) > 0 {
+		state := ViolationState(migratedState(row.Actions))
+		if !dryRun {
+			recordTransition(vEntity, violation, entityType, "", state, migrationTrigger, time.Now())
+		}
+		return state, time.Now()
+	}
+	return ViolationState(row.State), row.StateAt
+}
+
+// AdvanceState moves the violation to the state of the last done action that has one and records the transition
+// unless in shadow mode. Returns the state and since when it is in it.
+func AdvanceState(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, state ViolationState, stateAt time.Time, doneActions []DoneAction, dryRun bool) (ViolationState, time.Time) {
+	for _, doneAction := range doneActions {
+		if len(doneAction.State) == 0 || doneAction.State == state {
+			continue
+		}
+		if !isExpectedTransition(state, doneAction.State) {
+			refuseTransition(vEntity, violation, entityType, state, doneAction.State, doneAction.Name)
+			continue
+		}
+		if !dryRun {
+			recordTransition(vEntity, violation, entityType, state, doneAction.State, doneAction.Name, doneAction.At)
+		}
+		state = doneAction.State
+		stateAt = doneAction.At
+	}
+	if len(state) == 0 && len(doneActions) > 0 {
+		// a new violation that none of the done actions moved
+		if !dryRun {
+			recordTransition(vEntity, violation, entityType, "", ObservedState, doneActions[0].Name, doneActions[0].At)
+		}
+		return ObservedState, doneActions[0].At
+	}
+	return state, stateAt
+}
+
+// ExemptViolation moves an exempted violation to the exempted state, its action history is kept
+// for when the exemption ends.
+func ExemptViolation(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, dryRun bool) {
+	row := db.SelectVActionRow(vEntity, violation, entityType)
+	if dryRun {
+		row = db.SelectShadowVActionRow(vEntity, violation, entityType)
+	}
+	state, _ := CurrentState(vEntity, violation, entityType, row, dryRun)
+	if state == ExemptedState {
+		return
+	}
+
+	now := time.Now()
+	if dryRun {
+		db.InsertShadowVactionRow(vEntity.Namespace, entityType, vEntity.Name, string(violation.Type), violation.Source, row.Actions, string(ExemptedState), now)
+		return
+	}
+	recordTransition(vEntity, violation, entityType, state, ExemptedState, exemptionTrigger, now)
+	db.InsertVactionRow(vEntity.Namespace, entityType, vEntity.Name, string(violation.Type), violation.Source, row.Actions, string(ExemptedState), now)
+}
+
+// restartLifecycle starts the warnings of a violation over, e.g. after an operator restored the entity.
+func restartLifecycle(namespace string, entityType string, name string, violation violations.Violation, trigger string) {
+	vEntity := libs.ViolatableEntity{Namespace: namespace, Name: name}
+	state, _ := CurrentState(vEntity, violation, entityType, db.SelectVActionRow(vEntity, violation, entityType), false)
+
+	now := time.Now()
+	if state != ObservedState {
+		recordTransition(vEntity, violation, entityType, state, ObservedState, trigger, now)
+	}
+	db.InsertVactionRow(namespace, entityType, name, string(violation.Type), violation.Source, map[string][]time.Time{}, string(ObservedState), now)
+}
+
+// resolveViolation ends the lifecycle of a violation that is no longer reported.
+func resolveViolation(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, row db.VActionRow, trigger string) {
+	if len(row.ExpiredState) == 0 || ViolationState(row.ExpiredState) == ResolvedState {
+		return
+	}
+	now := time.Now()
+	recordTransition(vEntity, violation, entityType, ViolationState(row.ExpiredState), ResolvedState, trigger, now)
+	db.ExpireVactionRow(vEntity.Namespace, entityType, vEntity.Name, string(violation.Type), violation.Source, string(ResolvedState), now)
+}
+
+// ResolveExpiredViolations resolves the violations that were not reported for DurationViolationExpires,
+// at the time their row expired. Returns how many were resolved.
+func ResolveExpiredViolations() int {
+	expired := db.SelectExpiredVactionRows(string(ResolvedState))
+	for _, row := range expired {
+		vEntity := libs.ViolatableEntity{Namespace: row.Namespace, Name: row.Source}
+		violation := violations.Violation{Type: violations.ViolationType(row.VType), Source: row.VSource}
+		recordTransition(vEntity, violation, row.Type, ViolationState(row.ExpiredState), ResolvedState, expiryTrigger, row.ExpiredAt)
+		db.ExpireVactionRow(row.Namespace, row.Type, row.Source, row.VType, row.VSource, string(ResolvedState), row.ExpiredAt)
+	}
+	return len(expired)
+}
+
+// WatchExpiredViolations resolves expired violations every interval, it never returns.
+func WatchExpiredViolations(interval time.Duration) {
+	for {
+		if resolved := ResolveExpiredViolations(); resolved > 0 {
+			libs.Log.Info("Resolved ", resolved, " expired violations")
+		}
+		time.Sleep(interval)
+	}
+}
+
+// recordTransition records the transition, one that is not in violationTransitions is refused.
+func recordTransition(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, from ViolationState, to ViolationState, trigger string, at time.Time) {
+	if !isExpectedTransition(from, to) {
+		refuseTransition(vEntity, violation, entityType, from, to, trigger)
+		return
+	}
+	libs.Log.Debug("Violation ", violation.Type, " of ", entityType, " ", vEntity.Name, " in namespace ", vEntity.Namespace, " is ", to, " by ", trigger)
+	db.InsertTransitionRow(db.TransitionRow{
+		Namespace: vEntity.Namespace,
+		Type:      entityType,
+		Source:    vEntity.Name,
+		VType:     string(violation.Type),
+		VSource:   violation.Source,
+		From:      string(from),
+		To:        string(to),
+		Trigger:   trigger,
+		CreatedAt: at,
+	})
+}
+
+func refuseTransition(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, from ViolationState, to ViolationState, trigger string) {
+	libs.Log.Error("Refusing transition of ", violation.Type, " of ", entityType, " ", vEntity.Name, " in namespace ", vEntity.Namespace, " from ", from, " to ", to, " by ", trigger)
+}
+
+func isExpectedTransition(from ViolationState, to ViolationState) bool {
+	for _, state := range violationTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTransitions returns the transitions of the violations of an entity, or of a namespace when kind is empty.
+func ListTransitions(namespace string, kind string, name string) []db.TransitionRow {
+	entityType := ""
+	if len(kind) > 0 {
+		entityType = "Action" + strings.TrimPrefix(kind, "Action")
+	}
+	return db.SelectTransitionRows(namespace, entityType, name)
+}
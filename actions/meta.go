@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// objectMetaOf looks the entity up through the object cache.
+func objectMetaOf(entity ActionableEntity) (metav1.ObjectMeta, error) {
+	var meta metav1.ObjectMeta
+	switch a := entity.(type) {
+	case ActionNamespace:
+		kns, err := kube.Cache().Namespace(a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kns.ObjectMeta
+	case ActionPod:
+		kp, err := kube.Cache().Pod(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kp.ObjectMeta
+	case ActionReplicaSet:
+		krs, err := kube.Cache().ReplicaSet(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = krs.ObjectMeta
+	case ActionJob:
+		kj, err := kube.Cache().Job(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kj.ObjectMeta
+	case ActionDeployment:
+		kd, err := kube.Cache().Deployment(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kd.ObjectMeta
+	case ActionDaemonSet:
+		kds, err := kube.Cache().DaemonSet(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kds.ObjectMeta
+	case ActionIngress:
+		ki, err := kube.Cache().Ingress(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = ki.ObjectMeta
+	case ActionCronJob:
+		if libs.Cfg.IncludeAlpha == false {
+			return metav1.ObjectMeta{}, fmt.Errorf("CronJob %s is ignored as alpha features are not enabled", a.Name)
+		}
+		kcj, err := kube.Cache().CronJob(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kcj.ObjectMeta
+	case ActionStatefulSet:
+		kss, err := kube.Cache().StatefulSet(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = kss.ObjectMeta
+	case ActionReplicationController:
+		krc, err := kube.Cache().ReplicationController(a.Namespace, a.Name)
+		if err != nil {
+			return metav1.ObjectMeta{}, err
+		}
+		meta = krc.ObjectMeta
+	default:
+		return metav1.ObjectMeta{}, fmt.Errorf("Unknown Actionable Entity Type %s", reflect.TypeOf(entity).Name())
+	}
+
+	return meta, nil
+}
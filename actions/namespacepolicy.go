@@ -0,0 +1,128 @@
+package actions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+// Namespace annotations that override the escalation policy for the namespace.
+const (
+	safeModeAnnotation            = "k8guard.io/safe-mode"
+	warningCountAnnotation        = "k8guard.io/warning-count"
+	notifyIntervalAnnotation      = "k8guard.io/notify-interval"
+	exemptViolationTypeAnnotation = "k8guard.io/exempt-violation-types"
+	requireApprovalAnnotation     = "k8guard.io/require-approval"
+)
+
+// escalationForNamespace resolves the escalation policy of the violation type and applies the namespace
+// policy resources and then the overrides annotated on the namespace, invalid overrides are logged and ignored.
+func escalationForNamespace(namespace string, violationType violations.ViolationType) escalation {
+	e := namespacePolicyOf(namespace).apply(escalationFor(violationType))
+
+	ns, err := kube.Cache().Namespace(namespace)
+	if err != nil {
+		libs.Log.Warn("Not applying the overrides of namespace ", namespace, " as it could not be read: ", err)
+		return e
+	}
+
+	for annotation, value := range ns.Annotations {
+		value = strings.TrimSpace(value)
+		var invalid error
+
+		switch annotation {
+		case safeModeAnnotation:
+			safeMode, err := strconv.ParseBool(value)
+			if err != nil {
+				invalid = err
+				break
+			}
+			e.SafeMode = safeMode
+		case warningCountAnnotation:
+			warningCount, err := strconv.Atoi(value)
+			if err != nil || warningCount < 0 {
+				invalid = fmt.Errorf("%q is not a warning count", value)
+				break
+			}
+			e.WarningCount = warningCount
+		case notifyIntervalAnnotation:
+			notifyInterval, err := time.ParseDuration(value)
+			if err != nil || notifyInterval < 0 {
+				invalid = fmt.Errorf("%q is not a notify interval", value)
+				break
+			}
+			e.NotifyInterval = notifyInterval
+		case requireApprovalAnnotation:
+			requireApproval, err := strconv.ParseBool(value)
+			if err != nil {
+				invalid = err
+				break
+			}
+			e.RequireApproval = requireApproval
+		default:
+			continue
+		}
+
+		if invalid != nil {
+			libs.Log.Warn("Ignoring annotation ", annotation, " of namespace ", namespace, ": ", invalid)
+			continue
+		}
+		e.Overrides = append(e.Overrides, annotation)
+	}
+
+	sort.Strings(e.Overrides)
+	return e
+}
+
+// exemptsViolationType tells if the value of exemptViolationTypeAnnotation lists the violation type.
+func exemptsViolationType(value string, violationType violations.ViolationType) bool {
+	for _, exempt := range strings.Split(value, ",") {
+		if violations.ViolationType(strings.TrimSpace(exempt)) == violationType {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceExemptionOf returns an exemption for the violation when its namespace annotates its type as exempt.
+func NamespaceExemptionOf(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) (db.ExemptionRow, bool) {
+	ns, err := kube.Cache().Namespace(vEntity.Namespace)
+	if err != nil {
+		libs.Log.Warn("Not checking the exemptions of namespace ", vEntity.Namespace, " as it could not be read: ", err)
+		return db.ExemptionRow{}, false
+	}
+	if !exemptsViolationType(ns.Annotations[exemptViolationTypeAnnotation], violation.Type) {
+		return db.ExemptionRow{}, false
+	}
+	return db.ExemptionRow{
+		Namespace: vEntity.Namespace,
+		Type:      entityType,
+		Source:    vEntity.Name,
+		VType:     string(violation.Type),
+		VSource:   violation.Source,
+		Reason:    "annotated with " + exemptViolationTypeAnnotation,
+		CreatedBy: "namespace " + vEntity.Namespace,
+	}, true
+}
+
+// String describes the effective settings for the logs.
+func (e escalation) String() string {
+	overrides := "none"
+	if len(e.Overrides) > 0 {
+		overrides = strings.Join(e.Overrides, ",")
+	}
+	action := "remediation of the kind"
+	if len(e.Action) > 0 {
+		action = string(e.Action)
+	}
+	return fmt.Sprintf("severity %s, safe mode %t, allowed %t, action %s, %d warnings, notify every %s, approval %t, namespace overrides %s",
+		e.Severity, e.SafeMode, e.Allowed, action, e.WarningCount, e.NotifyInterval, e.RequireApproval, overrides)
+}
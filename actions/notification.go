@@ -10,12 +10,12 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/k8guard/k8guard-action/kube"
+
 	libs "github.com/k8guard/k8guardlibs"
-	"github.com/k8guard/k8guardlibs/k8s"
 	"github.com/nlopes/slack"
 	"github.com/tbruyelle/hipchat-go/hipchat"
 	"gopkg.in/gomail.v2"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/pkg/api/v1"
 )
 
@@ -38,13 +38,34 @@ Violation in namespace <b>{{.Namespace}}</b> in <b>{{.Cluster}}</b>:
 <ul>
 <li>{{.EntityType}}: {{.EntitySource}}</li>
 <li>Violation: {{.ViolationType}}</li>
+<li>Severity: {{.Severity}}</li>
 <li>Source: {{.ViolationSource}}</li>
 <li>Warning Count: {{.WarningCount}}</li>
+{{if .DecisionReason}}<li>Decision: {{.DecisionReason}}</li>{{end}}
 </ul>
+{{if .OffenseHistory}}
+Actions taken on it before:
+<ul>
+{{range .OffenseHistory}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}
 
 {{if .LastWarning}}
 <b>This is the last warning before taking action!</b>
 {{end}}
+{{if .GracePeriodEnd}}
+<b>This is for your information only, the grace period for new entities ends {{.GracePeriodEnd}}. Warnings after that count toward taking action.</b>
+{{end}}
+{{if .PendingApproval}}
+<b>Action waits for approval: {{.PendingApproval}}.</b>
+{{end}}
+{{if .NextEnforcement}}
+<b>Action is deferred to the next enforcement window, {{.NextEnforcement}}.</b>
+{{end}}
+{{if .AppliedFix}}
+<b>The violation was fixed by patching the pod template with:</b>
+<pre>{{.AppliedFix}}</pre>
+{{end}}
 </p>
 `
 
@@ -57,6 +78,21 @@ type actionMessage struct {
 	ViolationSource string
 	WarningCount    int
 	LastWarning     bool
+	// The patch applied by the auto-fix remediation
+	AppliedFix string
+	// When a deferred action will be taken
+	NextEnforcement string
+	Severity        Severity
+	// When the grace period of a new entity ends
+	GracePeriodEnd string
+	// The action waiting for approval
+	PendingApproval string
+	// Why the decision policy decided
+	DecisionReason string
+	// The actions taken on the entity before, the latest first
+	OffenseHistory []string
+	// routes the message to the channels of the severity
+	policy escalation
 }
 
 func NotifyOfViolation(actionMessage actionMessage) {
@@ -72,19 +108,29 @@ func NotifyOfViolation(actionMessage actionMessage) {
 		panic(err)
 	}
 
-	clientset, err := k8s.LoadClientset()
-	if err != nil {
-		panic(err)
+	if actionMessage.policy.DryRun {
+		notifyShadow(tpl.String())
+		return
 	}
 
-	ns, err := clientset.CoreV1().Namespaces().Get(actionMessage.Namespace, metav1.GetOptions{})
+	ns, err := kube.Cache().Namespace(actionMessage.Namespace)
 	if err != nil {
 		panic(err)
 	}
 
-	go notifyHipChat(tpl.String(), ns, actionMessage.LastWarning)
-	go notifySlack(tpl.String(), ns, actionMessage.LastWarning)
-	notifyEmail(tpl.String(), ns, actionMessage.LastWarning)
+	if actionMessage.policy.notifiesOn(HipchatChannel) {
+		go notifyHipChat(tpl.String(), ns, actionMessage.LastWarning)
+	}
+	if actionMessage.policy.notifiesOn(SlackChannel) {
+		slackChannel := libs.Cfg.SlackChannel
+		if len(actionMessage.policy.SlackChannel) > 0 {
+			slackChannel = actionMessage.policy.SlackChannel
+		}
+		go notifySlack(tpl.String(), slackChannel, ns, actionMessage.LastWarning)
+	}
+	if actionMessage.policy.notifiesOn(EmailChannel) {
+		notifyEmail(tpl.String(), ns, actionMessage.LastWarning)
+	}
 
 }
 
@@ -146,7 +192,8 @@ func notifyHipChat(message string, namespace *v1.Namespace, lastWarning bool) {
 
 }
 
-func notifySlack(message string, namespace *v1.Namespace, lastWarning bool) {
+func notifySlack(message string, channel string, namespace *v1.Namespace, lastWarning bool) {
+	lastSlackMutex.Lock()
 	canChat := time.Now().Sub(lastSlack) < libs.Cfg.DurationBetweenChatNotifications
 	if canChat {
 		time.Sleep(libs.Cfg.DurationBetweenChatNotifications)
@@ -154,7 +201,7 @@ func notifySlack(message string, namespace *v1.Namespace, lastWarning bool) {
 	lastSlack = time.Now()
 	lastSlackMutex.Unlock()
 	api := slack.New(libs.Cfg.SlackToken)
-	_, _, err := api.PostMessage(libs.Cfg.SlackChannel, message, slack.PostMessageParameters{})
+	_, _, err := api.PostMessage(channel, message, slack.PostMessageParameters{})
 	if err != nil {
 		libs.Log.Error(err)
 	}
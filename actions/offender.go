@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+)
+
+// RepeatOffenderPolicy escalates the violations of an entity faster once it was acted on a number of times,
+// for any violation and also when the violations expired since. The step with the most offenses that
+// the entity reached applies, it never slows down the escalation.
+type RepeatOffenderPolicy struct {
+	// Actions taken on the entity within K8GUARD_ACTION_OFFENSE_RETENTION.
+	Offenses int `json:"offenses"`
+	// 0 acts on the first violation.
+	WarningCount *int `json:"warningCount,omitempty"`
+	// e.g. "1h"
+	NotifyInterval string `json:"notifyInterval,omitempty"`
+}
+
+func validateRepeatOffenders(steps []RepeatOffenderPolicy) []string {
+	problems := []string{}
+	seen := map[int]bool{}
+	for _, step := range steps {
+		name := fmt.Sprintf("repeatOffenders with %d offenses", step.Offenses)
+		if step.Offenses < 1 {
+			problems = append(problems, name+" must have at least 1 offense")
+		}
+		if seen[step.Offenses] {
+			problems = append(problems, name+" is listed twice")
+		}
+		seen[step.Offenses] = true
+		if step.WarningCount != nil && *step.WarningCount < 0 {
+			problems = append(problems, name+" has a negative warningCount")
+		}
+		if len(step.NotifyInterval) > 0 {
+			if _, err := time.ParseDuration(step.NotifyInterval); err != nil {
+				problems = append(problems, fmt.Sprintf("%s has an invalid notifyInterval %q", name, step.NotifyInterval))
+			}
+		}
+	}
+	return problems
+}
+
+// forOffenses applies the repeat offender step the offenses of the entity reached.
+func (e escalation) forOffenses(offenses []db.OffenseRow, steps []RepeatOffenderPolicy) escalation {
+	e.Offenses = offenses
+	var step *RepeatOffenderPolicy
+	for i := range steps {
+		if len(offenses) >= steps[i].Offenses && (step == nil || steps[i].Offenses > step.Offenses) {
+			step = &steps[i]
+		}
+	}
+	if step == nil {
+		return e
+	}
+
+	if step.WarningCount != nil && *step.WarningCount < e.WarningCount {
+		e.WarningCount = *step.WarningCount
+	}
+	if len(step.NotifyInterval) > 0 {
+		// validated when loaded
+		if notifyInterval, _ := time.ParseDuration(step.NotifyInterval); notifyInterval < e.NotifyInterval {
+			e.NotifyInterval = notifyInterval
+		}
+	}
+	e.Overrides = append(e.Overrides, fmt.Sprintf("repeat offender with %d offenses", len(offenses)))
+	return e
+}
+
+// offenseHistory describes the offenses for the notifications, the latest first.
+func offenseHistory(offenses []db.OffenseRow) []string {
+	history := []string{}
+	for _, offense := range offenses {
+		history = append(history, fmt.Sprintf("%s %s for %s", offense.CreatedAt.Format("Mon 2006-01-02 15:04 MST"), offense.Remediation, offense.VType))
+	}
+	return history
+}
+
+// isOffense tells if an action taken in the state is an offense, every time the violation gets actioned.
+// That is once per lifecycle and again each time the owner undid the action and it was taken again.
+func isOffense(state ViolationState, status ActionStatus) bool {
+	return status == SuccessStatus && isExpectedTransition(state, ActionedState)
+}
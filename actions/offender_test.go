@@ -0,0 +1,76 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+func TestIsOffense(t *testing.T) {
+	tests := []struct {
+		state  ViolationState
+		status ActionStatus
+		want   bool
+	}{
+		{"", SuccessStatus, true},
+		{WarnedState, SuccessStatus, true},
+		{LastWarningState, SuccessStatus, true},
+		{PendingActionState, SuccessStatus, true},
+		{ActionedState, SuccessStatus, false},
+		{LastWarningState, ErrorStatus, false},
+		{LastWarningState, BlockedStatus, false},
+		{LastWarningState, ShadowStatus, false},
+	}
+	for _, test := range tests {
+		if got := isOffense(test.state, test.status); got != test.want {
+			t.Errorf("isOffense(%q, %q) = %t, want %t", test.state, test.status, got, test.want)
+		}
+	}
+}
+
+// The owner scales the deployment back up after it was scaled to zero, it is acted on again and that is
+// a second offense, which reaches the repeat offender step.
+func TestOffenseWhenUndoneActionIsTakenAgain(t *testing.T) {
+	vEntity := libs.ViolatableEntity{Namespace: "team", Name: "web"}
+	violation := violations.Violation{Type: violations.PRIVILEGED_TYPE, Source: "web"}
+	offenses := []db.OffenseRow{}
+	state, stateAt := LastWarningState, time.Now()
+
+	scans := [][]DoneAction{
+		// the action after the last warning
+		{{Name: EntityActionName, Status: SuccessStatus, State: ActionedState}},
+		// the scale to zero still holds
+		{},
+		// scaled back up by hand
+		{{Name: EntityUndoneActionName, Status: SuccessStatus, State: PendingActionState}, {Name: EntityActionName, Status: SuccessStatus, State: ActionedState}},
+	}
+	for _, doneActions := range scans {
+		actedIn := state
+		for _, doneAction := range doneActions {
+			if doneAction.Name == EntityActionName && isOffense(actedIn, doneAction.Status) {
+				offenses = append(offenses, db.OffenseRow{Remediation: string(ScaleToZeroRemediation), VType: string(violation.Type)})
+			}
+			if len(doneAction.State) > 0 {
+				actedIn = doneAction.State
+			}
+		}
+		state, stateAt = AdvanceState(vEntity, violation, "ActionDeployment", state, stateAt, doneActions, true)
+	}
+
+	if state != ActionedState {
+		t.Errorf("state = %q, want %q", state, ActionedState)
+	}
+	if len(offenses) != 2 {
+		t.Fatalf("%d offenses, want 2", len(offenses))
+	}
+
+	warningCount := 0
+	policy := escalation{WarningCount: 3, NotifyInterval: time.Hour}.forOffenses(offenses, []RepeatOffenderPolicy{{Offenses: 2, WarningCount: &warningCount}})
+	if policy.WarningCount != 0 {
+		t.Errorf("WarningCount = %d after %d offenses, want 0", policy.WarningCount, len(offenses))
+	}
+}
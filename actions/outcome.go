@@ -0,0 +1,90 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type ActionStatus string
+
+const (
+	SuccessStatus   ActionStatus = "success"
+	NotFoundStatus  ActionStatus = "not-found"
+	ForbiddenStatus ActionStatus = "forbidden"
+	ConflictStatus  ActionStatus = "conflict"
+	ErrorStatus     ActionStatus = "error"
+	// The action was deliberately not taken, e.g. alpha features are disabled.
+	SkippedStatus ActionStatus = "skipped"
+	// The action is due but waits for the next enforcement window.
+	DeferredStatus ActionStatus = "deferred"
+	// The circuit breaker is tripped, the action is taken once it is reset.
+	BlockedStatus ActionStatus = "blocked"
+	// Shadow mode, the action would have been taken.
+	ShadowStatus ActionStatus = "shadow"
+	// The kind has no such remediation, trying again won't help.
+	UnsupportedStatus ActionStatus = "unsupported"
+)
+
+// What came out of an action on an entity.
+type ActionOutcome struct {
+	Status ActionStatus
+	Err    error
+	// What was changed, e.g. the patch applied by auto-fix.
+	Detail string
+}
+
+// Names of the done actions, as stored in the action log and counted in the action history of a violation.
+const (
+	NotifyActionName = "notify"
+	// a notification in the grace period, it does not count as a warning
+	NotifyGraceActionName       = "notify_grace"
+	EntityActionName            = "entity_action"
+	EntityActionDeferredName    = "entity_action_deferred"
+	EntityActionPendingName     = "entity_action_pending"
+	PolicySkipActionName        = "policy_skip"
+	ApprovalActionName          = "approval"
+	EntityRestoreActionName     = "entity_restore"
+	EntityReapplyActionName     = "entity_reapply"
+	QuarantineReleaseActionName = "quarantine_release"
+	// the action was undone outside of k8guard, e.g. a deployment scaled back up by hand
+	EntityUndoneActionName = "entity_undone"
+)
+
+// An action done for a violation, as stored in the action log.
+type DoneAction struct {
+	Name   string
+	Status ActionStatus
+	Detail string
+	// Why the decision policy decided on it.
+	Reason string
+	At     time.Time
+	// The lifecycle state the action moves the violation to, empty when it stays where it is.
+	State ViolationState
+}
+
+func outcomeOf(err error) ActionOutcome {
+	switch {
+	case err == nil:
+		return ActionOutcome{Status: SuccessStatus}
+	case apierrors.IsNotFound(err):
+		return ActionOutcome{Status: NotFoundStatus, Err: err}
+	case apierrors.IsForbidden(err):
+		return ActionOutcome{Status: ForbiddenStatus, Err: err}
+	case apierrors.IsConflict(err):
+		return ActionOutcome{Status: ConflictStatus, Err: err}
+	default:
+		return ActionOutcome{Status: ErrorStatus, Err: err}
+	}
+}
+
+func unsupportedOutcome(remediation Remediation, kind string) ActionOutcome {
+	return ActionOutcome{Status: UnsupportedStatus, Err: fmt.Errorf("%s does not support %s", kind, remediation)}
+}
+
+// Retry tells if the action should be tried again on the next scan instead of being counted as done,
+// a missing entity or missing permissions won't be fixed by trying again.
+func (s ActionStatus) Retry() bool {
+	return s == ConflictStatus || s == ErrorStatus || s == BlockedStatus
+}
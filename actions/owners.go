@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResolveController follows the owner references of a pod up to its top level controller,
+// so the action is taken on and notified about the controller instead of a pod it would recreate.
+// The pod itself is returned when it has no controller or the chain can not be followed.
+func ResolveController(pod ActionPod) ActionableEntity {
+	kind, name := "Pod", pod.Name
+
+	for {
+		owners, err := ownerReferencesOf(kind, pod.Namespace, name)
+		if err != nil {
+			libs.Log.Warn("Acting on Pod ", pod.Name, " as its owners could not be resolved: ", err)
+			return pod
+		}
+
+		controller := controllerOf(owners)
+		if controller == nil {
+			break
+		}
+		kind, name = controller.Kind, controller.Name
+	}
+
+	if kind == "Pod" {
+		return pod
+	}
+
+	vEntity := pod.ViolatableEntity
+	vEntity.Name = name
+	entity, err := newActionableEntityFor(kind, vEntity)
+	if err != nil {
+		libs.Log.Warn("Acting on Pod ", pod.Name, " as its controller can not be acted on: ", err)
+		return pod
+	}
+
+	libs.Log.Debug("Resolved Pod ", pod.Name, " in namespace ", pod.Namespace, " to ", reflect.TypeOf(entity).Name(), " ", name)
+	return entity
+}
+
+func controllerOf(owners []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range owners {
+		if owners[i].Controller != nil && *owners[i].Controller {
+			return &owners[i]
+		}
+	}
+	return nil
+}
+
+func ownerReferencesOf(kind string, namespace string, name string) ([]metav1.OwnerReference, error) {
+	var meta metav1.ObjectMeta
+	switch kind {
+	case "Pod":
+		kp, err := kube.Cache().Pod(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		meta = kp.ObjectMeta
+	case "ReplicaSet":
+		krs, err := kube.Cache().ReplicaSet(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		meta = krs.ObjectMeta
+	case "Job":
+		kj, err := kube.Cache().Job(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		meta = kj.ObjectMeta
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicationController", "CronJob":
+		// top level controllers
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("Unknown owner kind %s", kind)
+	}
+
+	return meta.OwnerReferences, nil
+}
@@ -0,0 +1,62 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func ownedBy(namespace string, name string, kind string, owner string) metav1.ObjectMeta {
+	controller := true
+	meta := metav1.ObjectMeta{Namespace: namespace, Name: name}
+	if len(kind) > 0 {
+		meta.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner, Controller: &controller}}
+	}
+	return meta
+}
+
+func TestResolveController(t *testing.T) {
+	previous := kube.Cache()
+	defer kube.SetCache(previous)
+
+	fake := kube.NewFakeCache()
+	fake.Pods["team/web-1-a"] = &v1.Pod{ObjectMeta: ownedBy("team", "web-1-a", "ReplicaSet", "web-1")}
+	fake.ReplicaSets["team/web-1"] = &v1beta1.ReplicaSet{ObjectMeta: ownedBy("team", "web-1", "Deployment", "web")}
+	fake.Pods["team/backup-1-a"] = &v1.Pod{ObjectMeta: ownedBy("team", "backup-1-a", "Job", "backup-1")}
+	fake.Jobs["team/backup-1"] = &batchv1.Job{ObjectMeta: ownedBy("team", "backup-1", "CronJob", "backup")}
+	fake.Pods["team/db-0"] = &v1.Pod{ObjectMeta: ownedBy("team", "db-0", "StatefulSet", "db")}
+	fake.Pods["team/debug"] = &v1.Pod{ObjectMeta: ownedBy("team", "debug", "", "")}
+	// the replica set is gone
+	fake.Pods["team/orphan-1-a"] = &v1.Pod{ObjectMeta: ownedBy("team", "orphan-1-a", "ReplicaSet", "orphan-1")}
+	fake.Pods["team/custom"] = &v1.Pod{ObjectMeta: ownedBy("team", "custom", "Operator", "custom")}
+	kube.SetCache(fake)
+
+	tests := []struct {
+		pod      string
+		wantKind string
+		wantName string
+	}{
+		{"web-1-a", "ActionDeployment", "web"},
+		{"backup-1-a", "ActionCronJob", "backup"},
+		{"db-0", "ActionStatefulSet", "db"},
+		{"debug", "ActionPod", "debug"},
+		{"orphan-1-a", "ActionPod", "orphan-1-a"},
+		{"custom", "ActionPod", "custom"},
+	}
+	for _, test := range tests {
+		pod := ActionPod{ViolatableEntity: libs.ViolatableEntity{Namespace: "team", Name: test.pod}}
+		entity := ResolveController(pod)
+		kind := reflect.TypeOf(entity).Name()
+		name := reflect.ValueOf(entity).FieldByName("Name").String()
+		if kind != test.wantKind || name != test.wantName {
+			t.Errorf("ResolveController(%s) = %s %s, want %s %s", test.pod, kind, name, test.wantKind, test.wantName)
+		}
+	}
+}
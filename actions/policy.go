@@ -0,0 +1,267 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+// EscalationPolicy is the policy document, in YAML or JSON, that defines how each violation type escalates.
+type EscalationPolicy struct {
+	// Applies to every violation type, unset fields fall back to the k8guard config.
+	Default ViolationPolicy `json:"default"`
+	// Keyed by severity, unset fields fall back to the default.
+	Severities map[Severity]SeverityPolicy `json:"severities"`
+	// Keyed by violation type, unset fields fall back to the policy of its severity.
+	ViolationTypes map[string]ViolationPolicy `json:"violationTypes"`
+	// Escalate entities that were acted on before faster, see RepeatOffenderPolicy.
+	RepeatOffenders []RepeatOffenderPolicy `json:"repeatOffenders,omitempty"`
+}
+
+type ViolationPolicy struct {
+	// How long to wait before notifying again, e.g. "24h".
+	NotifyInterval string `json:"notifyInterval,omitempty"`
+	// How many warnings are sent before acting, 0 acts on the first violation.
+	WarningCount *int `json:"warningCount,omitempty"`
+	// The remediation to take, used for every kind that supports it.
+	Action Remediation `json:"action,omitempty"`
+	// Whether any action is allowed at all, when false the violation is only notified.
+	Allowed *bool `json:"allowed,omitempty"`
+	// Only for violation types, see defaultSeverities.
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// SeverityPolicy is the escalation of every violation type of a severity and where they are notified.
+type SeverityPolicy struct {
+	ViolationPolicy
+	// Some of hipchat, slack and email, all of them when empty.
+	Channels []string `json:"channels,omitempty"`
+	// Overrides the slack channel from the k8guard config.
+	SlackChannel string `json:"slackChannel,omitempty"`
+}
+
+// escalation is the resolved policy of a violation type.
+type escalation struct {
+	NotifyInterval time.Duration
+	WarningCount   int
+	Action         Remediation
+	Allowed        bool
+	SafeMode       bool
+	Severity       Severity
+	Channels       []string
+	SlackChannel   string
+	// set from the namespace policy resources and annotations, see escalationForNamespace
+	RequireApproval bool
+	Overrides       []string
+	// the actions taken on the entity before, see forOffenses
+	Offenses []db.OffenseRow
+	// why the decision policy decided, see decide
+	DecisionReason string
+	// shadow mode, nothing is notified or acted on and what would have been done goes to the shadow log
+	DryRun bool
+}
+
+// What used to be hard-coded, single replica and image size are only notified and
+// ingresses are acted on without warnings. A policy file can override these.
+var builtinViolationPolicies = map[string]ViolationPolicy{
+	string(violations.SINGLE_REPLICA_TYPE):       {Allowed: boolPtr(false)},
+	string(violations.IMAGE_SIZE_TYPE):           {Allowed: boolPtr(false)},
+	string(violations.INGRESS_HOST_INVALID_TYPE): {WarningCount: intPtr(0)},
+}
+
+var escalationPolicy = EscalationPolicy{Severities: builtinSeverityPolicies, ViolationTypes: builtinViolationPolicies}
+
+// the policy of the file, the policy resources are merged over it
+var filePolicy = escalationPolicy
+
+// policyMutex guards the escalation policy and the remediations, the policy resources replace them
+// while violations are processed.
+var policyMutex sync.RWMutex
+
+func currentEscalationPolicy() EscalationPolicy {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	return escalationPolicy
+}
+
+// LoadEscalationPolicy reads and validates the policy file, without a file the built-in policy is used.
+func LoadEscalationPolicy(path string) error {
+	fromFile := EscalationPolicy{}
+	if len(path) > 0 {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		err = yaml.Unmarshal(content, &fromFile)
+		if err != nil {
+			return fmt.Errorf("Invalid escalation policy %s: %v", path, err)
+		}
+	}
+
+	loaded := mergeEscalationPolicy(EscalationPolicy{Severities: builtinSeverityPolicies, ViolationTypes: builtinViolationPolicies}, fromFile)
+	problems := validateEscalationPolicy(loaded)
+	if len(problems) > 0 {
+		return errors.New("Invalid escalation policy: " + strings.Join(problems, "; "))
+	}
+
+	policyMutex.Lock()
+	filePolicy = loaded
+	escalationPolicy = loaded
+	policyMutex.Unlock()
+	for vType := range loaded.ViolationTypes {
+		libs.Log.Info("Escalation of ", vType, ": ", escalationFor(violations.ViolationType(vType)))
+	}
+	return nil
+}
+
+// mergeEscalationPolicy returns a copy of the base policy with the fields the override sets.
+func mergeEscalationPolicy(base EscalationPolicy, override EscalationPolicy) EscalationPolicy {
+	merged := EscalationPolicy{
+		Default:        mergeViolationPolicy(base.Default, override.Default),
+		Severities:     map[Severity]SeverityPolicy{},
+		ViolationTypes: map[string]ViolationPolicy{},
+	}
+	for severity, policy := range base.Severities {
+		merged.Severities[severity] = policy
+	}
+	for vType, policy := range base.ViolationTypes {
+		merged.ViolationTypes[vType] = policy
+	}
+
+	for severity, policy := range override.Severities {
+		m := merged.Severities[severity]
+		m.ViolationPolicy = mergeViolationPolicy(m.ViolationPolicy, policy.ViolationPolicy)
+		if len(policy.Channels) > 0 {
+			m.Channels = policy.Channels
+		}
+		if len(policy.SlackChannel) > 0 {
+			m.SlackChannel = policy.SlackChannel
+		}
+		merged.Severities[severity] = m
+	}
+	for vType, policy := range override.ViolationTypes {
+		merged.ViolationTypes[vType] = mergeViolationPolicy(merged.ViolationTypes[vType], policy)
+	}
+	merged.RepeatOffenders = base.RepeatOffenders
+	if len(override.RepeatOffenders) > 0 {
+		merged.RepeatOffenders = override.RepeatOffenders
+	}
+	return merged
+}
+
+func validateEscalationPolicy(policy EscalationPolicy) []string {
+	problems := validateViolationPolicy("default", "", policy.Default)
+	if len(policy.Default.Severity) > 0 {
+		problems = append(problems, "default can not set a severity")
+	}
+	for severity, severityPolicy := range policy.Severities {
+		problems = append(problems, validateSeverityPolicy(severity, severityPolicy)...)
+	}
+	for vType, violationPolicy := range policy.ViolationTypes {
+		if !isKnownViolationType(violations.ViolationType(vType)) {
+			problems = append(problems, fmt.Sprintf("unknown violation type %s, use one of %v", vType, knownViolationTypes))
+		}
+		problems = append(problems, validateViolationPolicy(vType, violations.ViolationType(vType), violationPolicy)...)
+	}
+	return append(problems, validateRepeatOffenders(policy.RepeatOffenders)...)
+}
+
+func validateViolationPolicy(name string, vType violations.ViolationType, policy ViolationPolicy) []string {
+	problems := []string{}
+	if len(policy.NotifyInterval) > 0 {
+		if _, err := time.ParseDuration(policy.NotifyInterval); err != nil {
+			problems = append(problems, fmt.Sprintf("%s has an invalid notifyInterval %q", name, policy.NotifyInterval))
+		}
+	}
+	if len(policy.Severity) > 0 && !isKnownSeverity(policy.Severity) {
+		problems = append(problems, fmt.Sprintf("%s has an unknown severity %s, use one of %v", name, policy.Severity, severities))
+	}
+	if policy.WarningCount != nil && *policy.WarningCount < 0 {
+		problems = append(problems, fmt.Sprintf("%s has a negative warningCount", name))
+	}
+	if len(policy.Action) > 0 {
+		if isKnownRemediation(policy.Action) == false {
+			problems = append(problems, fmt.Sprintf("%s has an unknown action %s", name, policy.Action))
+		} else if policy.Action == AutoFixRemediation && isAutoFixable(vType) == false {
+			problems = append(problems, fmt.Sprintf("%s: %s only works for one of %v", name, policy.Action, autoFixableViolationTypes))
+		}
+	}
+	return problems
+}
+
+func mergeViolationPolicy(base ViolationPolicy, override ViolationPolicy) ViolationPolicy {
+	if len(override.NotifyInterval) > 0 {
+		base.NotifyInterval = override.NotifyInterval
+	}
+	if override.WarningCount != nil {
+		base.WarningCount = override.WarningCount
+	}
+	if len(override.Action) > 0 {
+		base.Action = override.Action
+	}
+	if override.Allowed != nil {
+		base.Allowed = override.Allowed
+	}
+	if len(override.Severity) > 0 {
+		base.Severity = override.Severity
+	}
+	return base
+}
+
+// escalationFor resolves the policy of a violation type over the policy of its severity,
+// the default policy and the k8guard config.
+func escalationFor(violationType violations.ViolationType) escalation {
+	severity := SeverityOf(violationType)
+	current := currentEscalationPolicy()
+	severityPolicy := current.Severities[severity]
+	policy := mergeViolationPolicy(current.Default, severityPolicy.ViolationPolicy)
+	policy = mergeViolationPolicy(policy, current.ViolationTypes[string(violationType)])
+
+	e := escalation{
+		NotifyInterval: libs.Cfg.DurationBetweenNotifyingAgain,
+		WarningCount:   libs.Cfg.WarningCountBeforeAction,
+		Action:         policy.Action,
+		Allowed:        true,
+		SafeMode:       libs.Cfg.ActionSafeMode,
+		Severity:       severity,
+		Channels:       severityPolicy.Channels,
+		SlackChannel:   severityPolicy.SlackChannel,
+	}
+	if len(policy.NotifyInterval) > 0 {
+		// validated when loaded
+		e.NotifyInterval, _ = time.ParseDuration(policy.NotifyInterval)
+	}
+	if policy.WarningCount != nil {
+		e.WarningCount = *policy.WarningCount
+	}
+	if policy.Allowed != nil {
+		e.Allowed = *policy.Allowed
+	}
+	return e
+}
+
+func isKnownRemediation(remediation Remediation) bool {
+	for _, supported := range supportedRemediations {
+		if isSupportedRemediation(supported, remediation) {
+			return true
+		}
+	}
+	return false
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func intPtr(i int) *int {
+	return &i
+}
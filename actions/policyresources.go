@@ -0,0 +1,548 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The custom resources of crds/, see K8guardActionPolicy and K8guardNamespacePolicy.
+const (
+	policyResourcesPath       = "/apis/k8guard.io/v1alpha1"
+	actionPoliciesResource    = "k8guardactionpolicies"
+	namespacePoliciesResource = "k8guardnamespacepolicies"
+	// an entity is governed by a policy resource while its violations were seen in the last day
+	governedWindow = 24 * time.Hour
+)
+
+// K8guardActionPolicy is the cluster-scoped policy resource, the valid ones are merged over the config
+// in the order of their names.
+type K8guardActionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ActionPolicySpec `json:"spec"`
+	Status            PolicyStatus     `json:"status"`
+}
+
+type ActionPolicySpec struct {
+	// As in the escalation policy file, set fields override it.
+	Escalation EscalationPolicy `json:"escalation"`
+	// Kind[:ViolationType] to remediation as in K8GUARD_ACTION_REMEDIATIONS, overrides it per key.
+	Remediations map[string]Remediation `json:"remediations,omitempty"`
+	Exemptions   []Exemption            `json:"exemptions,omitempty"`
+}
+
+// K8guardNamespacePolicy is the namespaced policy resource, it overrides the escalation and the remediations
+// of the cluster for its namespace. The namespace annotations win over it.
+type K8guardNamespacePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              NamespacePolicySpec `json:"spec"`
+	Status            PolicyStatus        `json:"status"`
+}
+
+type NamespacePolicySpec struct {
+	SafeMode     *bool `json:"safeMode,omitempty"`
+	WarningCount *int  `json:"warningCount,omitempty"`
+	// e.g. "24h"
+	NotifyInterval  string `json:"notifyInterval,omitempty"`
+	RequireApproval *bool  `json:"requireApproval,omitempty"`
+	// Kind[:ViolationType] to remediation, wins over the remediations of the cluster.
+	Remediations map[string]Remediation `json:"remediations,omitempty"`
+	// Only exempts entities of the namespace of the policy.
+	Exemptions []Exemption `json:"exemptions,omitempty"`
+}
+
+// Exemption is an exemption as added by the exempt command, empty fields match everything
+// and the names may contain * wildcards.
+type Exemption struct {
+	Namespace       string `json:"namespace,omitempty"`
+	Kind            string `json:"kind,omitempty"`
+	Name            string `json:"name,omitempty"`
+	ViolationType   string `json:"violationType,omitempty"`
+	ViolationSource string `json:"violationSource,omitempty"`
+	// 2006-01-02 or RFC3339, empty never expires.
+	Until  string `json:"until,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PolicyStatus is written to each policy resource on every sync.
+type PolicyStatus struct {
+	// An invalid policy is not applied.
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+	// Entities whose violations it escalated or exempted in the last day.
+	GovernedEntities int `json:"governedEntities"`
+}
+
+type policyResourceList struct {
+	Items []json.RawMessage `json:"items"`
+}
+
+// namespacePolicy is the merged valid namespace policy resources of a namespace.
+type namespacePolicy struct {
+	names        []string
+	spec         NamespacePolicySpec
+	remediations map[string]Remediation
+}
+
+// the policy resources that are applied, guarded by policyMutex
+var (
+	actionPolicyNames = []string{}
+	policyExemptions  = []db.ExemptionRow{}
+	namespacePolicies = map[string]namespacePolicy{}
+	// the specs of the applied resources, to log when they change
+	appliedPolicySpecs = ""
+)
+
+// per policy resource, when each entity it governs was last seen
+var governed = struct {
+	sync.Mutex
+	seen map[string]map[string]time.Time
+}{seen: map[string]map[string]time.Time{}}
+
+func namespacePolicyOf(namespace string) namespacePolicy {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	return namespacePolicies[namespace]
+}
+
+// WatchPolicyResources applies the policy resources and writes their status every interval.
+func WatchPolicyResources(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		SyncPolicyResources()
+	}
+}
+
+// SyncPolicyResources merges the valid policy resources over the config and writes the status of each.
+// When they can not be listed the applied ones are kept, without the CRDs there are none.
+func SyncPolicyResources() {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		libs.Log.Error(err)
+		return
+	}
+	actionPolicies, err := listPolicyResources(clientset, actionPoliciesResource)
+	if err != nil {
+		libs.Log.Error("Keeping the applied policy resources, they could not be listed: ", err)
+		return
+	}
+	namespacedPolicies, err := listPolicyResources(clientset, namespacePoliciesResource)
+	if err != nil {
+		libs.Log.Error("Keeping the applied policy resources, they could not be listed: ", err)
+		return
+	}
+
+	mergedPolicy := filePolicy
+	mergedRemediations := map[string]Remediation{}
+	for key, remediation := range configuredRemediations {
+		mergedRemediations[key] = remediation
+	}
+	names := []string{}
+	exemptions := []db.ExemptionRow{}
+	namespaces := map[string]namespacePolicy{}
+	specs := []string{}
+	statuses := map[string]PolicyStatus{}
+
+	for _, raw := range actionPolicies {
+		resource := K8guardActionPolicy{}
+		if err := json.Unmarshal(raw, &resource); err != nil {
+			libs.Log.Error("Skipping an unreadable ", actionPoliciesResource, ": ", err)
+			continue
+		}
+		name := "K8guardActionPolicy/" + resource.Name
+
+		problems := validateEscalationPolicy(resource.Spec.Escalation)
+		remediationKeys, remediationProblems := parseRemediations(resource.Spec.Remediations)
+		exemptionRows, exemptionProblems := parseExemptions(name, resource.Spec.Exemptions, "")
+		problems = append(append(problems, remediationProblems...), exemptionProblems...)
+		statuses[name] = statusOf(problems)
+		if len(problems) > 0 {
+			continue
+		}
+
+		mergedPolicy = mergeEscalationPolicy(mergedPolicy, resource.Spec.Escalation)
+		for key, remediation := range remediationKeys {
+			mergedRemediations[key] = remediation
+		}
+		names = append(names, name)
+		exemptions = append(exemptions, exemptionRows...)
+		spec, _ := json.Marshal(resource.Spec)
+		specs = append(specs, name+string(spec))
+	}
+
+	for _, raw := range namespacedPolicies {
+		resource := K8guardNamespacePolicy{}
+		if err := json.Unmarshal(raw, &resource); err != nil {
+			libs.Log.Error("Skipping an unreadable ", namespacePoliciesResource, ": ", err)
+			continue
+		}
+		name := "K8guardNamespacePolicy/" + resource.Namespace + "/" + resource.Name
+
+		problems := validateNamespacePolicySpec(resource.Spec)
+		remediationKeys, remediationProblems := parseRemediations(resource.Spec.Remediations)
+		exemptionRows, exemptionProblems := parseExemptions(name, resource.Spec.Exemptions, resource.Namespace)
+		problems = append(append(problems, remediationProblems...), exemptionProblems...)
+		statuses[name] = statusOf(problems)
+		if len(problems) > 0 {
+			continue
+		}
+
+		namespaces[resource.Namespace] = namespaces[resource.Namespace].merge(name, resource.Spec, remediationKeys)
+		exemptions = append(exemptions, exemptionRows...)
+		spec, _ := json.Marshal(resource.Spec)
+		specs = append(specs, name+string(spec))
+	}
+
+	policyMutex.Lock()
+	escalationPolicy = mergedPolicy
+	remediations = mergedRemediations
+	actionPolicyNames = names
+	policyExemptions = exemptions
+	namespacePolicies = namespaces
+	changed := appliedPolicySpecs != strings.Join(specs, "\n")
+	appliedPolicySpecs = strings.Join(specs, "\n")
+	policyMutex.Unlock()
+	if changed && len(specs) == 0 {
+		libs.Log.Info("No policy resources are applied")
+	} else if changed {
+		libs.Log.Info("Applied the policy resources ", strings.Join(append(names, namespaceNames(namespaces)...), ", "))
+	}
+
+	counts := governedCounts(time.Now())
+	for _, raw := range actionPolicies {
+		writePolicyStatus(clientset, raw, "K8guardActionPolicy/", statuses, counts)
+	}
+	for _, raw := range namespacedPolicies {
+		writePolicyStatus(clientset, raw, "K8guardNamespacePolicy/", statuses, counts)
+	}
+}
+
+func statusOf(problems []string) PolicyStatus {
+	if len(problems) == 0 {
+		return PolicyStatus{Valid: true}
+	}
+	sort.Strings(problems)
+	return PolicyStatus{Errors: problems}
+}
+
+// listPolicyResources lists the resources of every namespace sorted by namespace and name.
+func listPolicyResources(clientset kubernetes.Interface, resource string) ([]json.RawMessage, error) {
+	raw, err := clientset.CoreV1().RESTClient().Get().AbsPath(policyResourcesPath, resource).DoRaw()
+	if apierrors.IsNotFound(err) {
+		libs.Log.Debug("No ", resource, ", the CRD is not installed")
+		return []json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := policyResourceList{}
+	err = json.Unmarshal(raw, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(list.Items))
+	for i, item := range list.Items {
+		meta := struct {
+			Metadata metav1.ObjectMeta `json:"metadata"`
+		}{}
+		json.Unmarshal(item, &meta)
+		keys[i] = meta.Metadata.Namespace + "/" + meta.Metadata.Name
+	}
+	sort.Sort(byKey{keys, list.Items})
+	return list.Items, nil
+}
+
+type byKey struct {
+	keys  []string
+	items []json.RawMessage
+}
+
+func (b byKey) Len() int           { return len(b.keys) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+}
+
+// writePolicyStatus updates the status of the resource when it changed, conflicts are retried on the next sync.
+func writePolicyStatus(clientset kubernetes.Interface, raw json.RawMessage, kind string, statuses map[string]PolicyStatus, counts map[string]int) {
+	object := map[string]interface{}{}
+	resource := struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Status   PolicyStatus      `json:"status"`
+	}{}
+	if json.Unmarshal(raw, &object) != nil || json.Unmarshal(raw, &resource) != nil {
+		return
+	}
+
+	resourcePath := path.Join(policyResourcesPath, actionPoliciesResource, resource.Metadata.Name)
+	name := kind + resource.Metadata.Name
+	if len(resource.Metadata.Namespace) > 0 {
+		resourcePath = path.Join(policyResourcesPath, "namespaces", resource.Metadata.Namespace, namespacePoliciesResource, resource.Metadata.Name)
+		name = kind + resource.Metadata.Namespace + "/" + resource.Metadata.Name
+	}
+
+	status, ok := statuses[name]
+	if !ok {
+		return
+	}
+	if status.Valid {
+		status.GovernedEntities = counts[name]
+	}
+	if reflect.DeepEqual(status, resource.Status) {
+		return
+	}
+	if !status.Valid && !reflect.DeepEqual(status.Errors, resource.Status.Errors) {
+		libs.Log.Error("Not applying the invalid ", name, ": ", strings.Join(status.Errors, "; "))
+	}
+
+	object["status"] = status
+	body, err := json.Marshal(object)
+	if err != nil {
+		libs.Log.Error(err)
+		return
+	}
+	err = clientset.CoreV1().RESTClient().Put().AbsPath(resourcePath).Body(body).Do().Error()
+	if err != nil {
+		libs.Log.Error("Could not write the status of ", name, ": ", err)
+	}
+}
+
+// parseRemediations validates the remediations of a policy resource and returns them by remediation key.
+func parseRemediations(spec map[string]Remediation) (map[string]Remediation, []string) {
+	parsed := map[string]Remediation{}
+	problems := []string{}
+	for key, remediation := range spec {
+		remediationKey, err := parseRemediation(strings.TrimSpace(key), remediation)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		parsed[remediationKey] = remediation
+	}
+	return parsed, problems
+}
+
+// parseExemptions turns the exemptions of a policy resource into exemption rows, created by the policy.
+// The exemptions of a namespace policy are limited to its namespace.
+func parseExemptions(policy string, exemptions []Exemption, namespace string) ([]db.ExemptionRow, []string) {
+	rows := []db.ExemptionRow{}
+	problems := []string{}
+	for i, exemption := range exemptions {
+		row := db.ExemptionRow{
+			Namespace: wildcardIfEmpty(exemption.Namespace),
+			Cluster:   libs.Cfg.ClusterName,
+			Type:      wildcardIfEmpty(exemption.Kind),
+			Source:    wildcardIfEmpty(exemption.Name),
+			VType:     wildcardIfEmpty(exemption.ViolationType),
+			VSource:   wildcardIfEmpty(exemption.ViolationSource),
+			Reason:    exemption.Reason,
+			CreatedBy: policy,
+		}
+		if len(namespace) > 0 {
+			if exemption.Namespace != "" && exemption.Namespace != namespace {
+				problems = append(problems, fmt.Sprintf("exemption %d can only exempt namespace %s", i, namespace))
+				continue
+			}
+			row.Namespace = namespace
+		}
+		if row.Type != db.ExemptionWildcard {
+			row.Type = "Action" + strings.TrimPrefix(row.Type, "Action")
+			if _, ok := supportedRemediations[row.Type]; !ok {
+				problems = append(problems, fmt.Sprintf("exemption %d has an unknown kind %s", i, exemption.Kind))
+				continue
+			}
+		}
+		if len(exemption.Until) > 0 {
+			var err error
+			row.ExpiresAt, err = time.Parse("2006-01-02", exemption.Until)
+			if err != nil {
+				row.ExpiresAt, err = time.Parse(time.RFC3339, exemption.Until)
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("exemption %d has an invalid until %q, use 2006-01-02 or RFC3339", i, exemption.Until))
+				continue
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, problems
+}
+
+func wildcardIfEmpty(value string) string {
+	if len(strings.TrimSpace(value)) == 0 {
+		return db.ExemptionWildcard
+	}
+	return strings.TrimSpace(value)
+}
+
+func validateNamespacePolicySpec(spec NamespacePolicySpec) []string {
+	problems := []string{}
+	if spec.WarningCount != nil && *spec.WarningCount < 0 {
+		problems = append(problems, "warningCount is negative")
+	}
+	if len(spec.NotifyInterval) > 0 {
+		if d, err := time.ParseDuration(spec.NotifyInterval); err != nil || d < 0 {
+			problems = append(problems, fmt.Sprintf("%q is not a notify interval", spec.NotifyInterval))
+		}
+	}
+	return problems
+}
+
+// merge applies a namespace policy resource over the ones before it.
+func (p namespacePolicy) merge(name string, spec NamespacePolicySpec, remediationKeys map[string]Remediation) namespacePolicy {
+	merged := namespacePolicy{names: append(append([]string{}, p.names...), name), spec: p.spec, remediations: map[string]Remediation{}}
+	for key, remediation := range p.remediations {
+		merged.remediations[key] = remediation
+	}
+	for key, remediation := range remediationKeys {
+		merged.remediations[key] = remediation
+	}
+	if spec.SafeMode != nil {
+		merged.spec.SafeMode = spec.SafeMode
+	}
+	if spec.WarningCount != nil {
+		merged.spec.WarningCount = spec.WarningCount
+	}
+	if len(spec.NotifyInterval) > 0 {
+		merged.spec.NotifyInterval = spec.NotifyInterval
+	}
+	if spec.RequireApproval != nil {
+		merged.spec.RequireApproval = spec.RequireApproval
+	}
+	return merged
+}
+
+// apply overrides the escalation with the namespace policy, validated when it was synced.
+func (p namespacePolicy) apply(e escalation) escalation {
+	if len(p.names) == 0 {
+		return e
+	}
+	if p.spec.SafeMode != nil {
+		e.SafeMode = *p.spec.SafeMode
+	}
+	if p.spec.WarningCount != nil {
+		e.WarningCount = *p.spec.WarningCount
+	}
+	if len(p.spec.NotifyInterval) > 0 {
+		e.NotifyInterval, _ = time.ParseDuration(p.spec.NotifyInterval)
+	}
+	if p.spec.RequireApproval != nil {
+		e.RequireApproval = *p.spec.RequireApproval
+	}
+	e.Overrides = append(e.Overrides, p.names...)
+	return e
+}
+
+func namespaceNames(namespaces map[string]namespacePolicy) []string {
+	names := []string{}
+	for _, p := range namespaces {
+		names = append(names, p.names...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PolicyExemptionOf returns the first exemption of the policy resources that matches the violation and did not expire.
+func PolicyExemptionOf(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) (db.ExemptionRow, bool) {
+	policyMutex.RLock()
+	exemptions := policyExemptions
+	policyMutex.RUnlock()
+
+	now := time.Now()
+	for _, exemption := range exemptions {
+		if (exemption.ExpiresAt.IsZero() || now.Before(exemption.ExpiresAt)) && exemption.Matches(vEntity, violation, entityType) {
+			observeGoverned([]string{exemption.CreatedBy}, vEntity.Namespace, entityType, vEntity.Name, now)
+			return exemption, true
+		}
+	}
+	return db.ExemptionRow{}, false
+}
+
+// governingPolicies are the applied policy resources that govern the violations of the namespace.
+func governingPolicies(namespace string) []string {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	return append(append([]string{}, actionPolicyNames...), namespacePolicies[namespace].names...)
+}
+
+func observeGoverned(policies []string, namespace string, entityType string, name string, now time.Time) {
+	governed.Lock()
+	defer governed.Unlock()
+	for _, policy := range policies {
+		if _, ok := governed.seen[policy]; !ok {
+			governed.seen[policy] = map[string]time.Time{}
+		}
+		governed.seen[policy][namespace+"/"+entityType+"/"+name] = now
+	}
+}
+
+// governedCounts counts the entities of each policy resource seen in the governed window and forgets the others.
+func governedCounts(now time.Time) map[string]int {
+	governed.Lock()
+	defer governed.Unlock()
+	counts := map[string]int{}
+	for policy, entities := range governed.seen {
+		for entity, seen := range entities {
+			if now.Sub(seen) > governedWindow {
+				delete(entities, entity)
+			}
+		}
+		if len(entities) == 0 {
+			delete(governed.seen, policy)
+			continue
+		}
+		counts[policy] = len(entities)
+	}
+	return counts
+}
+
+// ListPolicyResources returns the policy resources of the cluster with the status they were given.
+func ListPolicyResources() ([]K8guardActionPolicy, []K8guardNamespacePolicy, error) {
+	actionPolicies := []K8guardActionPolicy{}
+	namespacedPolicies := []K8guardNamespacePolicy{}
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return actionPolicies, namespacedPolicies, err
+	}
+
+	raws, err := listPolicyResources(clientset, actionPoliciesResource)
+	if err != nil {
+		return actionPolicies, namespacedPolicies, err
+	}
+	for _, raw := range raws {
+		resource := K8guardActionPolicy{}
+		if json.Unmarshal(raw, &resource) == nil {
+			actionPolicies = append(actionPolicies, resource)
+		}
+	}
+
+	raws, err = listPolicyResources(clientset, namespacePoliciesResource)
+	if err != nil {
+		return actionPolicies, namespacedPolicies, err
+	}
+	for _, raw := range raws {
+		resource := K8guardNamespacePolicy{}
+		if json.Unmarshal(raw, &resource) == nil {
+			namespacedPolicies = append(namespacedPolicies, resource)
+		}
+	}
+	return actionPolicies, namespacedPolicies, nil
+}
@@ -0,0 +1,315 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	quarantineLabel           = "k8guard.io/quarantine"
+	quarantineEntityTypeKey   = "k8guard.io/entity-type"
+	quarantineEntityNameKey   = "k8guard.io/entity-name"
+	quarantineViolationKey    = "k8guard.io/violation-type"
+	quarantineViolationSrcKey = "k8guard.io/violation-source"
+	// set to the uid of a bare pod so its quarantine selects only that pod
+	quarantinePodLabel = "k8guard.io/quarantine-pod"
+)
+
+// The typed client has no networking/v1 group yet and extensions/v1beta1 network policies can not deny egress.
+const networkPoliciesPath = "/apis/networking.k8s.io/v1/namespaces"
+
+// Entities whose pods can be quarantined with a network policy.
+type QuarantinableEntity interface {
+	// The selector of the pods of the entity.
+	PodSelector() (*metav1.LabelSelector, error)
+}
+
+func quarantinePolicyName(entityType string, name string) string {
+	return "k8guard-quarantine-" + strings.ToLower(strings.TrimPrefix(entityType, "Action")) + "-" + name
+}
+
+// quarantineEntity creates a deny all ingress and egress network policy for the pods of the entity.
+func quarantineEntity(entity ActionableEntity, vEntity libs.ViolatableEntity, violation violations.Violation) ActionOutcome {
+	quarantinable, ok := entity.(QuarantinableEntity)
+	if !ok {
+		return ActionOutcome{Status: SkippedStatus, Err: errors.New(reflect.TypeOf(entity).Name() + " can not be quarantined")}
+	}
+
+	selector, err := quarantinable.PodSelector()
+	if err != nil {
+		return outcomeOf(err)
+	}
+	// an empty selector would quarantine the whole namespace
+	if selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0) {
+		return ActionOutcome{Status: SkippedStatus, Err: errors.New(vEntity.Name + " has no pod selector to quarantine")}
+	}
+
+	entityType := reflect.TypeOf(entity).Name()
+	policy := map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":      quarantinePolicyName(entityType, vEntity.Name),
+			"namespace": vEntity.Namespace,
+			"labels": map[string]string{
+				ownerLabel:      "k8guard",
+				quarantineLabel: "true",
+			},
+			"annotations": map[string]string{
+				quarantineEntityTypeKey:   entityType,
+				quarantineEntityNameKey:   vEntity.Name,
+				quarantineViolationKey:    string(violation.Type),
+				quarantineViolationSrcKey: violation.Source,
+			},
+		},
+		"spec": map[string]interface{}{
+			"podSelector": selector,
+			// no rules, nothing gets in or out
+			"policyTypes": []string{"Ingress", "Egress"},
+		},
+	}
+	body, err := json.Marshal(policy)
+	if err != nil {
+		return outcomeOf(err)
+	}
+
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return outcomeOf(err)
+	}
+	libs.Log.Debug("Quarantining ", entityType, " ", vEntity.Name, " in namespace ", vEntity.Namespace)
+	err = clientset.CoreV1().RESTClient().Post().AbsPath(networkPoliciesPath, vEntity.Namespace, "networkpolicies").Body(body).Do().Error()
+	if apierrors.IsAlreadyExists(err) {
+		err = nil
+	}
+	outcome := outcomeOf(err)
+	outcome.Detail = quarantinePolicyName(entityType, vEntity.Name)
+	return outcome
+}
+
+// releaseQuarantine removes the quarantine network policy of an entity, a missing policy is not an error.
+func releaseQuarantine(namespace string, entityType string, name string) error {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return err
+	}
+	libs.Log.Debug("Releasing quarantine of ", entityType, " ", name, " in namespace ", namespace)
+	err = clientset.CoreV1().RESTClient().Delete().AbsPath(networkPoliciesPath, namespace, "networkpolicies", quarantinePolicyName(entityType, name)).Do().Error()
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+type quarantinePolicyList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+// ReleaseClearedQuarantines removes the quarantine of entities that are gone and of entities whose violation is
+// no longer reported, that is when the violation expired from vaction because discover stopped sending it.
+func ReleaseClearedQuarantines() {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		libs.Log.Error(err)
+		return
+	}
+
+	raw, err := clientset.CoreV1().RESTClient().Get().AbsPath("/apis/networking.k8s.io/v1/networkpolicies").
+		Param("labelSelector", quarantineLabel+"=true").DoRaw()
+	if err != nil {
+		libs.Log.Error("Could not list quarantine network policies: ", err)
+		return
+	}
+	policies := quarantinePolicyList{}
+	err = json.Unmarshal(raw, &policies)
+	if err != nil {
+		libs.Log.Error(err)
+		return
+	}
+
+	for _, policy := range policies.Items {
+		annotations := policy.Metadata.Annotations
+		entityType := annotations[quarantineEntityTypeKey]
+		vEntity := libs.ViolatableEntity{Name: annotations[quarantineEntityNameKey], Namespace: policy.Metadata.Namespace}
+		violation := violations.Violation{Type: violations.ViolationType(annotations[quarantineViolationKey]), Source: annotations[quarantineViolationSrcKey]}
+
+		vActionRow := db.SelectVActionRow(vEntity, violation, entityType)
+		if vActionRow.CreatedAt.IsZero() == false && entityExists(entityType, vEntity) {
+			continue
+		}
+		releaseClearedQuarantine(vEntity, entityType, violation, vActionRow)
+	}
+}
+
+// ReleaseFixedQuarantines removes the quarantine of an entity right away when discover reports it
+// without the violation it was quarantined for.
+func ReleaseFixedQuarantines(entity ActionableEntity, vEntity libs.ViolatableEntity, reported []violations.Violation) {
+	if _, ok := entity.(QuarantinableEntity); !ok {
+		return
+	}
+	entityType := reflect.TypeOf(entity).Name()
+	for _, stateRow := range db.SelectUnrestoredEntityStateRows(vEntity.Namespace, entityType, vEntity.Name) {
+		if Remediation(stateRow.Remediation) != QuarantineRemediation {
+			continue
+		}
+		fixed := true
+		for _, violation := range reported {
+			if string(violation.Type) == stateRow.VType && violation.Source == stateRow.VSource {
+				fixed = false
+			}
+		}
+		if fixed {
+			violation := violations.Violation{Type: violations.ViolationType(stateRow.VType), Source: stateRow.VSource}
+			releaseClearedQuarantine(vEntity, entityType, violation, db.SelectVActionRow(vEntity, violation, entityType))
+		}
+	}
+}
+
+func releaseClearedQuarantine(vEntity libs.ViolatableEntity, entityType string, violation violations.Violation, vActionRow db.VActionRow) {
+	libs.Log.Info("Violation ", violation.Type, " of ", entityType, " ", vEntity.Name, " cleared, releasing its quarantine")
+	err := releaseQuarantine(vEntity.Namespace, entityType, vEntity.Name)
+	if err != nil {
+		libs.Log.Error(err)
+		return
+	}
+	for _, stateRow := range db.SelectUnrestoredEntityStateRows(vEntity.Namespace, entityType, vEntity.Name) {
+		if Remediation(stateRow.Remediation) == QuarantineRemediation {
+			db.MarkEntityStateRowRestored(stateRow)
+		}
+	}
+	db.InsertActionLogRow(vEntity.Namespace, entityType, vEntity.Name, string(violation.Type), violation.Source, string(SeverityOf(violation.Type)), QuarantineReleaseActionName, string(SuccessStatus), quarantinePolicyName(entityType, vEntity.Name), "")
+	resolveViolation(vEntity, violation, entityType, vActionRow, QuarantineReleaseActionName)
+}
+
+// entityExists tells if the quarantined entity is still there, when in doubt it is.
+func entityExists(entityType string, vEntity libs.ViolatableEntity) bool {
+	entity, err := newActionableEntityFor(entityType, vEntity)
+	if err != nil {
+		return true
+	}
+	quarantinable, ok := entity.(QuarantinableEntity)
+	if !ok {
+		return true
+	}
+	_, err = quarantinable.PodSelector()
+	return !apierrors.IsNotFound(err)
+}
+
+// WatchQuarantines releases cleared quarantines every interval, it never returns.
+func WatchQuarantines(interval time.Duration) {
+	for {
+		ReleaseClearedQuarantines()
+		time.Sleep(interval)
+	}
+}
+
+// PodSelector of a bare pod labels it with its uid and selects that, its other labels may be shared with other pods.
+func (a ActionPod) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kp, err := clientset.CoreV1().Pods(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if kp.Labels[quarantinePodLabel] != string(kp.UID) {
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]string{quarantinePodLabel: string(kp.UID)}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = clientset.CoreV1().Pods(a.Namespace).Patch(a.Name, types.StrategicMergePatchType, patch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &metav1.LabelSelector{MatchLabels: map[string]string{quarantinePodLabel: string(kp.UID)}}, nil
+}
+
+func (a ActionDeployment) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kd, err := clientset.AppsV1beta1().Deployments(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return kd.Spec.Selector, nil
+}
+
+func (a ActionDaemonSet) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kds, err := clientset.ExtensionsV1beta1().DaemonSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return kds.Spec.Selector, nil
+}
+
+func (a ActionStatefulSet) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kss, err := clientset.AppsV1beta1().StatefulSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return kss.Spec.Selector, nil
+}
+
+func (a ActionReplicaSet) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	krs, err := clientset.ExtensionsV1beta1().ReplicaSets(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return krs.Spec.Selector, nil
+}
+
+func (a ActionReplicationController) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	krc, err := clientset.CoreV1().ReplicationControllers(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &metav1.LabelSelector{MatchLabels: krc.Spec.Selector}, nil
+}
+
+func (a ActionJob) PodSelector() (*metav1.LabelSelector, error) {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return nil, err
+	}
+	kj, err := clientset.BatchV1().Jobs(a.Namespace).Get(a.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return kj.Spec.Selector, nil
+}
@@ -0,0 +1,210 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+type Remediation string
+
+const (
+	DeleteRemediation       Remediation = "delete"
+	ScaleToZeroRemediation  Remediation = "scale-to-zero"
+	SuspendRemediation      Remediation = "suspend"
+	AnnotateRemediation     Remediation = "annotate-only"
+	LabelIsolateRemediation Remediation = "label-isolate"
+	NotifyOnlyRemediation   Remediation = "notify-only"
+	// Denies all traffic to and from the pods of the entity with a network policy, see quarantineEntity.
+	QuarantineRemediation Remediation = "quarantine"
+	// Namespaces only, stops new pods with a zero pod resource quota, see freezeNamespace.
+	FreezeRemediation Remediation = "freeze"
+	// Opt-in only, patches the pod template of the workload, see autoFixableViolationTypes.
+	AutoFixRemediation Remediation = "auto-fix"
+)
+
+const (
+	violationAnnotation = "k8guard.io/violation"
+	isolationLabel      = "k8guard.io/isolated"
+	ownerLabel          = "k8guard.io/owner"
+)
+
+// Remediations each entity kind supports, the first one is the default.
+var supportedRemediations = map[string][]Remediation{
+	"ActionPod":                   {DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation},
+	"ActionNamespace":             {DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, FreezeRemediation},
+	"ActionDeployment":            {ScaleToZeroRemediation, DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation, AutoFixRemediation},
+	"ActionDaemonSet":             {DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation, AutoFixRemediation},
+	"ActionIngress":               {DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation},
+	"ActionJob":                   {DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation},
+	"ActionCronJob":               {SuspendRemediation, DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation},
+	"ActionStatefulSet":           {ScaleToZeroRemediation, DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation},
+	"ActionReplicaSet":            {ScaleToZeroRemediation, DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation},
+	"ActionReplicationController": {ScaleToZeroRemediation, DeleteRemediation, AnnotateRemediation, LabelIsolateRemediation, NotifyOnlyRemediation, QuarantineRemediation},
+}
+
+// Remediations that record the previous state of the entity so they can be restored.
+var reversibleRemediations = []Remediation{ScaleToZeroRemediation, SuspendRemediation, FreezeRemediation}
+
+// Remediations that only run inside the enforcement windows, see deferredUntil.
+var destructiveRemediations = []Remediation{DeleteRemediation, ScaleToZeroRemediation, SuspendRemediation, QuarantineRemediation, FreezeRemediation, AutoFixRemediation}
+
+// configured remediations keyed by entity type or entity type:violation type,
+// the ones of the policy resources are merged over them and guarded by policyMutex
+var remediations = map[string]Remediation{}
+
+// the remediations of the config
+var configuredRemediations = remediations
+
+func currentRemediations() map[string]Remediation {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	return remediations
+}
+
+// LoadRemediations parses and validates a remediation spec such as
+// "Deployment=delete,Deployment:PRIVILEGED=label-isolate".
+func LoadRemediations(spec string) error {
+	loaded := map[string]Remediation{}
+	problems := []string{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			problems = append(problems, fmt.Sprintf("%q is not in the Kind[:ViolationType]=remediation format", entry))
+			continue
+		}
+
+		key, err := parseRemediation(strings.TrimSpace(parts[0]), Remediation(strings.TrimSpace(parts[1])))
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		loaded[key] = Remediation(strings.TrimSpace(parts[1]))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Invalid remediation config: " + strings.Join(problems, "; "))
+	}
+
+	policyMutex.Lock()
+	configuredRemediations = loaded
+	remediations = loaded
+	policyMutex.Unlock()
+	for key, remediation := range loaded {
+		libs.Log.Info("Using remediation ", remediation, " for ", key)
+	}
+	return nil
+}
+
+// parseRemediation validates the remediation of a Kind[:ViolationType] and returns its remediation key.
+func parseRemediation(key string, remediation Remediation) (string, error) {
+	entry := key + "=" + string(remediation)
+
+	kind := key
+	vType := ""
+	if i := strings.Index(key, ":"); i >= 0 {
+		kind = strings.TrimSpace(key[:i])
+		vType = strings.TrimSpace(key[i+1:])
+		if len(vType) == 0 {
+			return "", fmt.Errorf("%q has an empty violation type", entry)
+		}
+		if !isKnownViolationType(violations.ViolationType(vType)) {
+			return "", fmt.Errorf("%q has an unknown violation type %s, use one of %v", entry, vType, knownViolationTypes)
+		}
+	}
+
+	entityType := "Action" + strings.TrimPrefix(kind, "Action")
+	supported, ok := supportedRemediations[entityType]
+	if !ok {
+		return "", fmt.Errorf("%q has an unknown kind %s", entry, kind)
+	}
+	if !isSupportedRemediation(supported, remediation) {
+		return "", fmt.Errorf("%q: %s does not support %s, use one of %v", entry, kind, remediation, supported)
+	}
+
+	if remediation == AutoFixRemediation && isAutoFixable(violations.ViolationType(vType)) == false {
+		return "", fmt.Errorf("%q: %s only works per violation type, one of %v", entry, remediation, autoFixableViolationTypes)
+	}
+	return remediationKey(entityType, violations.ViolationType(vType)), nil
+}
+
+// remediationFor returns the remediation for the entity and violation type,
+// a violation type specific remediation wins over the action of the escalation policy
+// and that one wins over the remediation for the kind. The namespace policy resources
+// of the namespace win over the cluster wide remediations.
+func remediationFor(entity ActionableEntity, namespace string, violationType violations.ViolationType) Remediation {
+	entityType := reflect.TypeOf(entity).Name()
+	current := currentRemediations()
+	namespaceRemediations := namespacePolicyOf(namespace).remediations
+
+	for _, r := range []map[string]Remediation{namespaceRemediations, current} {
+		if remediation, ok := r[remediationKey(entityType, violationType)]; ok {
+			return remediation
+		}
+	}
+	if action := escalationFor(violationType).Action; len(action) > 0 && isSupportedRemediation(supportedRemediations[entityType], action) {
+		return action
+	}
+	for _, r := range []map[string]Remediation{namespaceRemediations, current} {
+		if remediation, ok := r[remediationKey(entityType, "")]; ok {
+			return remediation
+		}
+	}
+	if supported, ok := supportedRemediations[entityType]; ok {
+		return supported[0]
+	}
+	return NotifyOnlyRemediation
+}
+
+func remediationKey(entityType string, violationType violations.ViolationType) string {
+	if len(violationType) == 0 {
+		return entityType
+	}
+	return entityType + ":" + string(violationType)
+}
+
+func isSupportedRemediation(supported []Remediation, remediation Remediation) bool {
+	for _, r := range supported {
+		if r == remediation {
+			return true
+		}
+	}
+	return false
+}
+
+// remediationPatch builds the strategic merge patch for the annotate-only and label-isolate remediations,
+// withTemplate also labels the pod template so the pods of a workload are isolated too.
+func remediationPatch(remediation Remediation, violation violations.Violation, withTemplate bool) []byte {
+	metadata := map[string]interface{}{}
+
+	switch remediation {
+	case AnnotateRemediation:
+		metadata["annotations"] = map[string]string{violationAnnotation: string(violation.Type) + ": " + violation.Source}
+	case LabelIsolateRemediation:
+		metadata["labels"] = map[string]string{isolationLabel: "true"}
+	}
+
+	patch := map[string]interface{}{"metadata": metadata}
+	if withTemplate && remediation == LabelIsolateRemediation {
+		patch["spec"] = map[string]interface{}{
+			"template": map[string]interface{}{"metadata": metadata},
+		}
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		panic(err)
+	}
+	return patchBytes
+}
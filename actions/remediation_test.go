@@ -0,0 +1,93 @@
+package actions
+
+import (
+	"testing"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+func TestParseRemediation(t *testing.T) {
+	tests := []struct {
+		key         string
+		remediation Remediation
+		wantKey     string
+		wantErr     bool
+	}{
+		{"Deployment", DeleteRemediation, "ActionDeployment", false},
+		{"ActionPod", QuarantineRemediation, "ActionPod", false},
+		{"Deployment:" + string(violations.PRIVILEGED_TYPE), LabelIsolateRemediation, "ActionDeployment:" + string(violations.PRIVILEGED_TYPE), false},
+		{"Deployment:" + string(violations.PRIVILEGED_TYPE), AutoFixRemediation, "ActionDeployment:" + string(violations.PRIVILEGED_TYPE), false},
+		{"Namespace", FreezeRemediation, "ActionNamespace", false},
+		{"Deployment:", DeleteRemediation, "", true},
+		{"Deployment:NOT_A_TYPE", DeleteRemediation, "", true},
+		{"Widget", DeleteRemediation, "", true},
+		{"Ingress", ScaleToZeroRemediation, "", true},
+		{"Deployment", "shred", "", true},
+		// auto-fix only per violation type it can fix
+		{"Deployment", AutoFixRemediation, "", true},
+		{"Deployment:" + string(violations.IMAGE_SIZE_TYPE), AutoFixRemediation, "", true},
+	}
+	for _, test := range tests {
+		key, err := parseRemediation(test.key, test.remediation)
+		if key != test.wantKey || (err != nil) != test.wantErr {
+			t.Errorf("parseRemediation(%q, %q) = %q, %v, want %q and an error %t", test.key, test.remediation, key, err, test.wantKey, test.wantErr)
+		}
+	}
+}
+
+func TestRemediationFor(t *testing.T) {
+	previousRemediations, previousPolicy, previousNamespacePolicies := remediations, escalationPolicy, namespacePolicies
+	defer func() {
+		remediations, escalationPolicy, namespacePolicies = previousRemediations, previousPolicy, previousNamespacePolicies
+	}()
+
+	remediations = map[string]Remediation{
+		"ActionDeployment": DeleteRemediation,
+		"ActionDeployment:" + string(violations.CAPABILITIES_TYPE): LabelIsolateRemediation,
+		"ActionPod": AnnotateRemediation,
+	}
+	escalationPolicy = EscalationPolicy{ViolationTypes: map[string]ViolationPolicy{
+		string(violations.PRIVILEGED_TYPE):   {Action: QuarantineRemediation},
+		string(violations.CAPABILITIES_TYPE): {Action: QuarantineRemediation},
+		// not supported by deployments
+		string(violations.IMAGE_SIZE_TYPE): {Action: SuspendRemediation},
+	}}
+	namespacePolicies = map[string]namespacePolicy{"team": {remediations: map[string]Remediation{
+		"ActionDeployment": NotifyOnlyRemediation,
+		"ActionDeployment:" + string(violations.HOST_VOLUMES_TYPE): AnnotateRemediation,
+	}}}
+
+	entity := func(kind string) ActionableEntity {
+		e, err := newActionableEntityFor(kind, libs.ViolatableEntity{Name: "web"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	tests := []struct {
+		kind          string
+		namespace     string
+		violationType violations.ViolationType
+		want          Remediation
+	}{
+		// the remediation for the violation type wins over the action of the escalation policy
+		{"Deployment", "other", violations.CAPABILITIES_TYPE, LabelIsolateRemediation},
+		{"Deployment", "team", violations.HOST_VOLUMES_TYPE, AnnotateRemediation},
+		// the action of the escalation policy wins over the remediation for the kind
+		{"Deployment", "other", violations.PRIVILEGED_TYPE, QuarantineRemediation},
+		{"Deployment", "team", violations.PRIVILEGED_TYPE, QuarantineRemediation},
+		// an action the kind does not support is ignored, the namespace wins over the cluster
+		{"Deployment", "team", violations.IMAGE_SIZE_TYPE, NotifyOnlyRemediation},
+		{"Deployment", "other", violations.IMAGE_SIZE_TYPE, DeleteRemediation},
+		{"Pod", "other", violations.IMAGE_SIZE_TYPE, AnnotateRemediation},
+		// the default of the kind
+		{"StatefulSet", "other", violations.HOST_VOLUMES_TYPE, ScaleToZeroRemediation},
+		{"CronJob", "other", violations.PRIVILEGED_TYPE, SuspendRemediation},
+	}
+	for _, test := range tests {
+		if got := remediationFor(entity(test.kind), test.namespace, test.violationType); got != test.want {
+			t.Errorf("remediationFor(%s in %s, %s) = %s, want %s", test.kind, test.namespace, test.violationType, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/k8guard/k8guard-action/db"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RestoreEntity puts an entity back to the state recorded before its first reversible action that was not restored yet
+// or lifts its quarantine, marks the restore in the action log and restarts the warnings for the violations of the remediation.
+func RestoreEntity(kind string, namespace string, name string) error {
+	entity, err := newActionableEntity(kind, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	entityType := reflect.TypeOf(entity).Name()
+	stateRows := db.SelectUnrestoredEntityStateRows(namespace, entityType, name)
+	if len(stateRows) == 0 {
+		return errors.New(fmt.Sprintf("No recorded state for %s %s in namespace %s that was not restored yet", kind, name, namespace))
+	}
+	// the states recorded after it, if any, are of the entity after the action
+	stateRow := stateRows[len(stateRows)-1]
+
+	libs.Log.Info("Restoring ", entityType, " ", name, " in namespace ", namespace, " from ", stateRow.Remediation, " at ", stateRow.CreatedAt)
+	if Remediation(stateRow.Remediation) == QuarantineRemediation {
+		err = releaseQuarantine(namespace, entityType, name)
+	} else {
+		reversible, ok := entity.(ReversibleEntity)
+		if !ok {
+			return errors.New(fmt.Sprintf("%s does not support restoring", kind))
+		}
+		err = reversible.Restore(stateRow.State)
+	}
+	if err != nil {
+		return err
+	}
+
+	// every violation the remediation was taken for is restored, not only the first
+	restored := map[violations.Violation]bool{}
+	for _, r := range stateRows {
+		if r.Remediation == stateRow.Remediation {
+			db.MarkEntityStateRowRestored(r)
+			restored[violations.Violation{Type: violations.ViolationType(r.VType), Source: r.VSource}] = true
+		}
+	}
+	for violation := range restored {
+		db.InsertActionLogRow(namespace, entityType, name, string(violation.Type), violation.Source, severityOfType(string(violation.Type)), EntityRestoreActionName, string(SuccessStatus), "", "")
+		// start warning again instead of acting on the next scan
+		restartLifecycle(namespace, entityType, name, violation, EntityRestoreActionName)
+	}
+
+	return nil
+}
+
+// actionInEffect returns the oldest recorded state of the entity before an action for the violation that was not
+// restored yet and still holds on the live entity, the entity is not acted on again as long as there is one.
+// A frozen namespace is not frozen again for any violation, the second snapshot would have its workloads at zero.
+// The states of actions that were undone by hand are marked restored unless in shadow mode, undone tells if there was one.
+func actionInEffect(entity ActionableEntity, vEntity libs.ViolatableEntity, violation violations.Violation, remediation Remediation, dryRun bool) (db.EntityStateRow, bool, bool) {
+	undone := false
+	stateRows := db.SelectUnrestoredEntityStateRows(vEntity.Namespace, reflect.TypeOf(entity).Name(), vEntity.Name)
+	for i := len(stateRows) - 1; i >= 0; i-- {
+		forViolation := stateRows[i].VType == string(violation.Type) && stateRows[i].VSource == violation.Source
+		if !forViolation && !(remediation == FreezeRemediation && Remediation(stateRows[i].Remediation) == FreezeRemediation) {
+			continue
+		}
+		if stillInEffect(entity, vEntity, stateRows[i]) {
+			return stateRows[i], true, undone
+		}
+		libs.Log.Info("The ", stateRows[i].Remediation, " of ", vEntity.Name, " in namespace ", vEntity.Namespace, " at ", stateRows[i].CreatedAt, " was undone")
+		if !dryRun {
+			db.MarkEntityStateRowRestored(stateRows[i])
+		}
+		undone = true
+	}
+	return db.EntityStateRow{}, false, undone
+}
+
+// stillInEffect tells if the recorded action still holds on the live entity, when in doubt it does.
+func stillInEffect(entity ActionableEntity, vEntity libs.ViolatableEntity, stateRow db.EntityStateRow) bool {
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return true
+	}
+
+	switch Remediation(stateRow.Remediation) {
+	case QuarantineRemediation:
+		err = clientset.CoreV1().RESTClient().Get().AbsPath(networkPoliciesPath, vEntity.Namespace, "networkpolicies", stateRow.State["networkPolicy"]).Do().Error()
+		return !apierrors.IsNotFound(err)
+	case FreezeRemediation:
+		_, err = clientset.CoreV1().ResourceQuotas(vEntity.Name).Get(freezeQuotaName, metav1.GetOptions{})
+		return !apierrors.IsNotFound(err)
+	}
+
+	reversible, ok := entity.(ReversibleEntity)
+	if !ok {
+		return true
+	}
+	state, err := reversible.CurrentState()
+	if err != nil {
+		return true
+	}
+	switch Remediation(stateRow.Remediation) {
+	case ScaleToZeroRemediation:
+		return state["replicas"] == "0"
+	case SuspendRemediation:
+		return state["suspend"] == "true"
+	}
+	return true
+}
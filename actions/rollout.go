@@ -0,0 +1,73 @@
+package actions
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Percentage of the namespaces that are acted on, the others are in safe mode.
+var rolloutPercent = 100
+
+// LoadRollout sets the percentage of the namespaces that are acted on.
+func LoadRollout(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("Invalid rollout percentage %d, use 0 to 100", percent)
+	}
+	rolloutPercent = percent
+	libs.Log.Info("Acting on ", rolloutPercent, "% of the namespaces")
+	return nil
+}
+
+// rolloutBucket places the namespace in one of 100 buckets by a stable hash of its name,
+// so raising the percentage only adds namespaces.
+func rolloutBucket(namespace string) int {
+	h := fnv.New32a()
+	h.Write([]byte(namespace))
+	return int(h.Sum32() % 100)
+}
+
+func inRollout(namespace string) bool {
+	return rolloutBucket(namespace) < rolloutPercent
+}
+
+type RolloutNamespace struct {
+	Namespace string
+	Bucket    int
+	Enforced  bool
+}
+
+// Rollout is the current percentage and whether each namespace of the cluster is in it.
+type Rollout struct {
+	Percent    int
+	Namespaces []RolloutNamespace
+}
+
+// RolloutOf lists the namespaces of the cluster with their bucket, the enforced ones first.
+func RolloutOf() (Rollout, error) {
+	rollout := Rollout{Percent: rolloutPercent, Namespaces: []RolloutNamespace{}}
+
+	clientset, err := kube.Clientset()
+	if err != nil {
+		return rollout, err
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		return rollout, err
+	}
+	for _, ns := range namespaces.Items {
+		rollout.Namespaces = append(rollout.Namespaces, RolloutNamespace{Namespace: ns.Name, Bucket: rolloutBucket(ns.Name), Enforced: inRollout(ns.Name)})
+	}
+	sort.Slice(rollout.Namespaces, func(i, j int) bool {
+		if rollout.Namespaces[i].Bucket != rollout.Namespaces[j].Bucket {
+			return rollout.Namespaces[i].Bucket < rollout.Namespaces[j].Bucket
+		}
+		return rollout.Namespaces[i].Namespace < rollout.Namespaces[j].Namespace
+	})
+	return rollout, nil
+}
@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"fmt"
+	"testing"
+)
+
+// The buckets must never change, or raising the percentage would drop enforced namespaces.
+func TestRolloutBucket(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      int
+	}{
+		{"default", 94},
+		{"kube-system", 54},
+		{"team-a", 46},
+		{"team-b", 27},
+		{"", 61},
+	}
+	for _, test := range tests {
+		if got := rolloutBucket(test.namespace); got != test.want {
+			t.Errorf("rolloutBucket(%q) = %d, want %d", test.namespace, got, test.want)
+		}
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	previous := rolloutPercent
+	defer func() { rolloutPercent = previous }()
+
+	namespaces := []string{}
+	for i := 0; i < 1000; i++ {
+		namespaces = append(namespaces, fmt.Sprintf("team-%d", i))
+	}
+
+	enforced := map[string]bool{}
+	for _, percent := range []int{0, 1, 10, 50, 99, 100} {
+		if err := LoadRollout(percent); err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		for _, namespace := range namespaces {
+			if bucket := rolloutBucket(namespace); bucket < 0 || bucket > 99 {
+				t.Fatalf("rolloutBucket(%q) = %d, want 0 to 99", namespace, bucket)
+			}
+			if inRollout(namespace) {
+				count++
+			} else if enforced[namespace] {
+				t.Errorf("%s left the rollout when raising it to %d%%", namespace, percent)
+			}
+			enforced[namespace] = inRollout(namespace)
+		}
+		switch {
+		case percent == 0 && count != 0:
+			t.Errorf("%d namespaces in a 0%% rollout", count)
+		case percent == 100 && count != len(namespaces):
+			t.Errorf("%d of %d namespaces in a 100%% rollout", count, len(namespaces))
+		}
+	}
+
+	for _, percent := range []int{-1, 101} {
+		if err := LoadRollout(percent); err == nil {
+			t.Errorf("LoadRollout(%d) is valid, want an error", percent)
+		}
+	}
+}
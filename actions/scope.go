@@ -0,0 +1,189 @@
+package actions
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Scope is what happens to the violations of an entity.
+type Scope string
+
+const (
+	// Notified and acted on as the escalation policy says.
+	ActScope Scope = "act"
+	// Notified but never acted on, as in safe mode.
+	NotifyScope Scope = "notify"
+	// Only written to the violation log.
+	LogScope Scope = "log"
+)
+
+// ScopeFile is the document, in YAML or JSON, that scopes enforcement. An entity is notified when it is
+// in the notify selectors and also acted on when it is in the act selectors, otherwise it is only logged.
+type ScopeFile struct {
+	Act    ScopeSelectors `json:"act"`
+	Notify ScopeSelectors `json:"notify"`
+}
+
+// ScopeSelectors selects the entities that are included and not excluded, an empty include selects all.
+type ScopeSelectors struct {
+	Include Selectors `json:"include"`
+	Exclude Selectors `json:"exclude"`
+}
+
+// Selectors matches an entity when any of its fields matches, an empty field matches nothing.
+type Selectors struct {
+	// Namespace name globs, e.g. "team-*".
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Label selector of the namespace, e.g. "env in (dev,staging)".
+	NamespaceSelector string `json:"namespaceSelector,omitempty"`
+	// Label selector of the entity itself, e.g. "k8guard.io/enforce=true".
+	EntitySelector string `json:"entitySelector,omitempty"`
+}
+
+type selectors struct {
+	namespaces        []string
+	namespaceSelector labels.Selector
+	entitySelector    labels.Selector
+}
+
+type scopeSelectors struct {
+	include selectors
+	exclude selectors
+}
+
+var enforcementScope = struct {
+	act    scopeSelectors
+	notify scopeSelectors
+}{}
+
+// LoadScope reads the scope file, no file acts on every entity.
+func LoadScope(scopePath string) error {
+	if len(scopePath) == 0 {
+		libs.Log.Info("No scope file, every entity is in the act scope")
+		return nil
+	}
+	content, err := ioutil.ReadFile(scopePath)
+	if err != nil {
+		return err
+	}
+	scopeFile := ScopeFile{}
+	err = yaml.Unmarshal(content, &scopeFile)
+	if err != nil {
+		return fmt.Errorf("Invalid scope file %s: %v", scopePath, err)
+	}
+
+	problems := []string{}
+	enforcementScope.act, problems = parseScopeSelectors("act", scopeFile.Act, problems)
+	enforcementScope.notify, problems = parseScopeSelectors("notify", scopeFile.Notify, problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid scope file %s: %s", scopePath, strings.Join(problems, "; "))
+	}
+	libs.Log.Info("Scoped enforcement with ", scopePath)
+	return nil
+}
+
+func parseScopeSelectors(name string, s ScopeSelectors, problems []string) (scopeSelectors, []string) {
+	parsed := scopeSelectors{}
+	parsed.include, problems = parseSelectors(name+".include", s.Include, problems)
+	parsed.exclude, problems = parseSelectors(name+".exclude", s.Exclude, problems)
+	return parsed, problems
+}
+
+func parseSelectors(name string, s Selectors, problems []string) (selectors, []string) {
+	parsed := selectors{namespaces: s.Namespaces}
+	for _, glob := range s.Namespaces {
+		if _, err := path.Match(glob, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("%s has an invalid namespace glob %q", name, glob))
+		}
+	}
+	var err error
+	if len(s.NamespaceSelector) > 0 {
+		if parsed.namespaceSelector, err = labels.Parse(s.NamespaceSelector); err != nil {
+			problems = append(problems, fmt.Sprintf("%s has an invalid namespaceSelector: %v", name, err))
+		}
+	}
+	if len(s.EntitySelector) > 0 {
+		if parsed.entitySelector, err = labels.Parse(s.EntitySelector); err != nil {
+			problems = append(problems, fmt.Sprintf("%s has an invalid entitySelector: %v", name, err))
+		}
+	}
+	return parsed, problems
+}
+
+func (s selectors) empty() bool {
+	return len(s.namespaces) == 0 && s.namespaceSelector == nil && s.entitySelector == nil
+}
+
+// the entity labels are only looked up when an entity selector needs them
+type scopedEntity struct {
+	entity          ActionableEntity
+	namespace       string
+	namespaceLabels labels.Set
+	entityLabels    labels.Set
+	lookedUp        bool
+}
+
+func (e *scopedEntity) lookupLabels() (labels.Set, error) {
+	if !e.lookedUp {
+		meta, err := objectMetaOf(e.entity)
+		if err != nil {
+			return nil, err
+		}
+		e.entityLabels = labels.Set(meta.Labels)
+		e.lookedUp = true
+	}
+	return e.entityLabels, nil
+}
+
+func (s selectors) matches(e *scopedEntity) bool {
+	for _, glob := range s.namespaces {
+		if matched, _ := path.Match(glob, e.namespace); matched {
+			return true
+		}
+	}
+	if s.namespaceSelector != nil && s.namespaceSelector.Matches(e.namespaceLabels) {
+		return true
+	}
+	if s.entitySelector != nil {
+		entityLabels, err := e.lookupLabels()
+		if err != nil {
+			libs.Log.Warn("Not matching the labels of ", reflect.TypeOf(e.entity).Name(), " in namespace ", e.namespace, " as they could not be read: ", err)
+			return false
+		}
+		return s.entitySelector.Matches(entityLabels)
+	}
+	return false
+}
+
+func (s scopeSelectors) selects(e *scopedEntity) bool {
+	return (s.include.empty() || s.include.matches(e)) && !s.exclude.matches(e)
+}
+
+// ScopeOf tells what happens to the violations of the entity,
+// namespaces outside of the rollout are at most notified.
+func ScopeOf(entity ActionableEntity, namespace string) Scope {
+	e := &scopedEntity{entity: entity, namespace: namespace}
+	ns, err := kube.Cache().Namespace(namespace)
+	if err != nil {
+		libs.Log.Warn("Not matching the labels of namespace ", namespace, " as it could not be read: ", err)
+	} else {
+		e.namespaceLabels = labels.Set(ns.Labels)
+	}
+
+	if !enforcementScope.notify.selects(e) {
+		return LogScope
+	}
+	if !enforcementScope.act.selects(e) || !inRollout(namespace) {
+		return NotifyScope
+	}
+	return ActScope
+}
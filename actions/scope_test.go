@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/k8guard/k8guard-action/kube"
+
+	libs "github.com/k8guard/k8guardlibs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/pkg/api/v1"
+	appsv1beta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+)
+
+func TestScopeOf(t *testing.T) {
+	previousCache, previousScope, previousPercent := kube.Cache(), enforcementScope, rolloutPercent
+	defer func() {
+		kube.SetCache(previousCache)
+		enforcementScope, rolloutPercent = previousScope, previousPercent
+	}()
+
+	fake := kube.NewFakeCache()
+	fake.Namespaces["team-dev"] = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-dev", Labels: map[string]string{"env": "dev"}}}
+	fake.Namespaces["team-prod"] = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-prod", Labels: map[string]string{"env": "prod"}}}
+	fake.Deployments["team-dev/web"] = &appsv1beta1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "team-dev", Name: "web"}}
+	fake.Deployments["team-dev/legacy"] = &appsv1beta1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "team-dev", Name: "legacy", Labels: map[string]string{"k8guard.io/enforce": "false"}}}
+	kube.SetCache(fake)
+
+	problems := []string{}
+	enforcementScope.notify, problems = parseScopeSelectors("notify", ScopeSelectors{Include: Selectors{Namespaces: []string{"team-*"}}}, problems)
+	enforcementScope.act, problems = parseScopeSelectors("act", ScopeSelectors{
+		Include: Selectors{NamespaceSelector: "env in (dev,staging)"},
+		Exclude: Selectors{EntitySelector: "k8guard.io/enforce=false"},
+	}, problems)
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+
+	deployment := func(namespace string, name string) ActionableEntity {
+		return ActionDeployment{ViolatableEntity: libs.ViolatableEntity{Namespace: namespace, Name: name}}
+	}
+	tests := []struct {
+		entity    ActionableEntity
+		namespace string
+		percent   int
+		want      Scope
+	}{
+		{deployment("team-dev", "web"), "team-dev", 100, ActScope},
+		{deployment("team-dev", "web"), "team-dev", 0, NotifyScope},
+		{deployment("team-dev", "legacy"), "team-dev", 100, NotifyScope},
+		{deployment("team-prod", "web"), "team-prod", 100, NotifyScope},
+		{deployment("infra", "web"), "infra", 100, LogScope},
+	}
+	for _, test := range tests {
+		rolloutPercent = test.percent
+		if got := ScopeOf(test.entity, test.namespace); got != test.want {
+			t.Errorf("ScopeOf(%v in %s) with %d%% rolled out = %s, want %s", test.entity, test.namespace, test.percent, got, test.want)
+		}
+	}
+}
+
+func TestScopeSelectorsSelects(t *testing.T) {
+	parse := func(include Selectors, exclude Selectors) scopeSelectors {
+		parsed, problems := parseScopeSelectors("act", ScopeSelectors{Include: include, Exclude: exclude}, []string{})
+		if len(problems) > 0 {
+			t.Fatal(problems)
+		}
+		return parsed
+	}
+	entity := func(namespace string, namespaceLabels labels.Set, entityLabels labels.Set) *scopedEntity {
+		// looked up already, so the entity itself is never read
+		return &scopedEntity{namespace: namespace, namespaceLabels: namespaceLabels, entityLabels: entityLabels, lookedUp: true}
+	}
+	dev := labels.Set{"env": "dev"}
+	optedOut := labels.Set{"k8guard.io/enforce": "false"}
+
+	tests := []struct {
+		name      string
+		selectors scopeSelectors
+		entity    *scopedEntity
+		want      bool
+	}{
+		{"empty selects all", parse(Selectors{}, Selectors{}), entity("team-a", nil, nil), true},
+		{"included by glob", parse(Selectors{Namespaces: []string{"team-*"}}, Selectors{}), entity("team-a", nil, nil), true},
+		{"not included by glob", parse(Selectors{Namespaces: []string{"team-*"}}, Selectors{}), entity("infra", nil, nil), false},
+		{"included by namespace labels", parse(Selectors{NamespaceSelector: "env in (dev,staging)"}, Selectors{}), entity("infra", dev, nil), true},
+		{"not included by namespace labels", parse(Selectors{NamespaceSelector: "env in (dev,staging)"}, Selectors{}), entity("infra", labels.Set{"env": "prod"}, nil), false},
+		{"any include field selects", parse(Selectors{Namespaces: []string{"team-*"}, NamespaceSelector: "env=dev"}, Selectors{}), entity("infra", dev, nil), true},
+		{"excluded by entity labels", parse(Selectors{}, Selectors{EntitySelector: "k8guard.io/enforce=false"}), entity("team-a", nil, optedOut), false},
+		{"exclude wins over include", parse(Selectors{Namespaces: []string{"team-*"}}, Selectors{Namespaces: []string{"team-legacy"}}), entity("team-legacy", nil, nil), false},
+		{"not excluded", parse(Selectors{Namespaces: []string{"team-*"}}, Selectors{EntitySelector: "k8guard.io/enforce=false"}), entity("team-a", dev, labels.Set{}), true},
+	}
+	for _, test := range tests {
+		if got := test.selectors.selects(test.entity); got != test.want {
+			t.Errorf("%s: selects = %t, want %t", test.name, got, test.want)
+		}
+	}
+
+	_, problems := parseScopeSelectors("act", ScopeSelectors{
+		Include: Selectors{Namespaces: []string{"team-["}, NamespaceSelector: "env in (dev"},
+		Exclude: Selectors{EntitySelector: "=false"},
+	}, []string{})
+	if len(problems) != 3 {
+		t.Errorf("%d problems with an invalid glob and selectors, want 3: %v", len(problems), problems)
+	}
+}
@@ -0,0 +1,99 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+type Severity string
+
+const (
+	InfoSeverity     Severity = "info"
+	LowSeverity      Severity = "low"
+	HighSeverity     Severity = "high"
+	CriticalSeverity Severity = "critical"
+)
+
+var severities = []Severity{InfoSeverity, LowSeverity, HighSeverity, CriticalSeverity}
+
+// Notification channels a severity can be routed to.
+const (
+	HipchatChannel = "hipchat"
+	SlackChannel   = "slack"
+	EmailChannel   = "email"
+)
+
+var notificationChannels = []string{HipchatChannel, SlackChannel, EmailChannel}
+
+// Severity of each violation type unless the escalation policy sets one, unknown types are low.
+var defaultSeverities = map[violations.ViolationType]Severity{
+	violations.PRIVILEGED_TYPE:           CriticalSeverity,
+	violations.CAPABILITIES_TYPE:         HighSeverity,
+	violations.HOST_VOLUMES_TYPE:         HighSeverity,
+	violations.IMAGE_REPO_TYPE:           HighSeverity,
+	violations.INGRESS_HOST_INVALID_TYPE: HighSeverity,
+	violations.SINGLE_REPLICA_TYPE:       InfoSeverity,
+	violations.IMAGE_SIZE_TYPE:           InfoSeverity,
+}
+
+// Escalation of each severity unless the escalation policy overrides it. None, a severity escalates like
+// the default until a policy file says otherwise, so existing installs keep their escalation.
+var builtinSeverityPolicies = map[Severity]SeverityPolicy{}
+
+// SeverityOf returns the severity of the violation type, as set in the escalation policy or the default one.
+func SeverityOf(violationType violations.ViolationType) Severity {
+	if severity := currentEscalationPolicy().ViolationTypes[string(violationType)].Severity; len(severity) > 0 {
+		return severity
+	}
+	if severity, ok := defaultSeverities[violationType]; ok {
+		return severity
+	}
+	return LowSeverity
+}
+
+func severityOfType(violationType string) string {
+	return string(SeverityOf(violations.ViolationType(violationType)))
+}
+
+func isKnownSeverity(severity Severity) bool {
+	for _, s := range severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSeverityPolicy(severity Severity, policy SeverityPolicy) []string {
+	problems := []string{}
+	if !isKnownSeverity(severity) {
+		problems = append(problems, fmt.Sprintf("unknown severity %s, use one of %v", severity, severities))
+	}
+	if len(policy.Severity) > 0 {
+		problems = append(problems, fmt.Sprintf("severity %s can not set a severity", severity))
+	}
+	for _, channel := range policy.Channels {
+		known := false
+		for _, c := range notificationChannels {
+			known = known || c == channel
+		}
+		if !known {
+			problems = append(problems, fmt.Sprintf("severity %s has an unknown channel %s, use some of %v", severity, channel, notificationChannels))
+		}
+	}
+	return append(problems, validateViolationPolicy(string(severity), "", policy.ViolationPolicy)...)
+}
+
+// notifiesOn tells if a message goes to the channel, no channels means all of them.
+func (e escalation) notifiesOn(channel string) bool {
+	if len(e.Channels) == 0 {
+		return true
+	}
+	for _, c := range e.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package actions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// notifyShadow sends a would-be notification to the test channel, if there is one.
+func notifyShadow(message string) {
+	if len(config.Cfg.ShadowSlackChannel) == 0 {
+		return
+	}
+	go notifySlack("[shadow mode, nothing was sent to the owners] "+message, config.Cfg.ShadowSlackChannel, nil, false)
+}
+
+// What enforcement would have done over a period.
+type ShadowReport struct {
+	From time.Time
+	To   time.Time
+	// number of each kind of entry, e.g. notify or entity_action
+	Counts map[string]int
+	// the would-be entity actions by remediation, namespace and violation type
+	ActionsByRemediation   map[string]int
+	ActionsByNamespace     map[string]int
+	ActionsByViolationType map[string]int
+	Actions                []db.ShadowLogRow
+}
+
+// ShadowReportOf summarizes the shadow log from from until to.
+func ShadowReportOf(from time.Time, to time.Time) ShadowReport {
+	report := ShadowReport{
+		From:                   from,
+		To:                     to,
+		Counts:                 map[string]int{},
+		ActionsByRemediation:   map[string]int{},
+		ActionsByNamespace:     map[string]int{},
+		ActionsByViolationType: map[string]int{},
+		Actions:                []db.ShadowLogRow{},
+	}
+	for _, shadowLogRow := range db.SelectShadowLogRows(from, to) {
+		report.Counts[shadowLogRow.Action]++
+		if shadowLogRow.Action != EntityActionName {
+			continue
+		}
+		report.ActionsByRemediation[shadowLogRow.Detail]++
+		report.ActionsByNamespace[shadowLogRow.Namespace]++
+		report.ActionsByViolationType[shadowLogRow.VType]++
+		report.Actions = append(report.Actions, shadowLogRow)
+	}
+	return report
+}
+
+// ParseReportPeriod reads the since and until of a report as 2006-01-02 or RFC3339,
+// an empty since is a week ago and an empty until is now.
+func ParseReportPeriod(since string, until string) (time.Time, time.Time, error) {
+	from := time.Now().Add(-7 * 24 * time.Hour)
+	to := time.Now()
+	for _, bound := range []struct {
+		value string
+		t     *time.Time
+	}{{since, &from}, {until, &to}} {
+		if len(bound.value) == 0 {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", bound.value)
+		if err != nil {
+			t, err = time.Parse(time.RFC3339, bound.value)
+		}
+		if err != nil {
+			return from, to, fmt.Errorf("Invalid report time %q, use 2006-01-02 or RFC3339", bound.value)
+		}
+		*bound.t = t
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("The report period must end after it starts")
+	}
+	return from, to, nil
+}
+
+const shadowReportTemplate = `Shadow mode from {{.From}} until {{.To}}:
+{{range $action, $count := .Counts}}  {{$count}} {{$action}}
+{{end}}
+Would-be actions by remediation:
+{{range $remediation, $count := .ActionsByRemediation}}  {{$count}} {{$remediation}}
+{{end}}
+Would-be actions by namespace:
+{{range $namespace, $count := .ActionsByNamespace}}  {{$count}} {{$namespace}}
+{{end}}
+Would-be actions by violation type:
+{{range $vType, $count := .ActionsByViolationType}}  {{$count}} {{$vType}}
+{{end}}
+Would-be actions:
+{{range .Actions}}  {{.CreatedAt}} {{.Detail}} of {{.Type}} {{.Source}} in namespace {{.Namespace}} for {{.VType}}
+{{end}}`
+
+// String prints the report for the shadow-report command.
+func (r ShadowReport) String() string {
+	tmpl, err := template.New("shadowReport").Parse(shadowReportTemplate)
+	if err != nil {
+		panic(err)
+	}
+	var tpl bytes.Buffer
+	err = tmpl.Execute(&tpl, r)
+	if err != nil {
+		libs.Log.Error(err)
+	}
+	return tpl.String()
+}
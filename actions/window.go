@@ -0,0 +1,209 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// A weekly window in which destructive actions may run, e.g. Mon-Fri 09:00-17:00.
+// A window that ends before it starts runs over midnight into the next day.
+type enforcementWindow struct {
+	days  [7]bool
+	start int // minutes since midnight
+	end   int
+}
+
+// A change freeze, from and to are the first and the last frozen day.
+type freezeRange struct {
+	from time.Time
+	to   time.Time
+}
+
+type enforcementSchedule struct {
+	location *time.Location
+	// no windows means always
+	windows []enforcementWindow
+	freezes []freezeRange
+}
+
+var schedule = enforcementSchedule{location: time.UTC}
+
+// How far ahead to look for the next time destructive actions may run.
+const enforcementLookahead = 400
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// LoadEnforcementSchedule parses the enforcement windows, e.g. "Mon-Fri 09:00-17:00;Sat 10:00-12:00",
+// and the change freezes, e.g. "2026-12-20..2027-01-03;2026-11-26", in the timezone.
+func LoadEnforcementSchedule(windows string, freezes string, timezone string) error {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("Invalid enforcement timezone %q: %v", timezone, err)
+	}
+	loaded := enforcementSchedule{location: location}
+	problems := []string{}
+
+	for _, entry := range strings.Split(windows, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		window, err := parseEnforcementWindow(entry)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		loaded.windows = append(loaded.windows, window)
+	}
+
+	for _, entry := range strings.Split(freezes, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		dates := strings.SplitN(entry, "..", 2)
+		if len(dates) == 1 {
+			dates = append(dates, dates[0])
+		}
+		from, errFrom := time.ParseInLocation("2006-01-02", strings.TrimSpace(dates[0]), location)
+		to, errTo := time.ParseInLocation("2006-01-02", strings.TrimSpace(dates[1]), location)
+		if errFrom != nil || errTo != nil || to.Before(from) {
+			problems = append(problems, fmt.Sprintf("%q is not a 2006-01-02[..2006-01-02] freeze", entry))
+			continue
+		}
+		loaded.freezes = append(loaded.freezes, freezeRange{from: from, to: to})
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Invalid enforcement schedule: " + strings.Join(problems, "; "))
+	}
+
+	schedule = loaded
+	libs.Log.Info("Destructive actions run in ", len(schedule.windows), " enforcement windows (none means always) in ", location, " with ", len(schedule.freezes), " change freezes")
+	return nil
+}
+
+func parseEnforcementWindow(entry string) (enforcementWindow, error) {
+	window := enforcementWindow{}
+	invalid := fmt.Errorf("%q is not a Mon-Fri 09:00-17:00 window", entry)
+
+	fields := strings.Fields(entry)
+	if len(fields) != 2 {
+		return window, invalid
+	}
+
+	for _, days := range strings.Split(fields[0], ",") {
+		bounds := strings.SplitN(strings.ToLower(days), "-", 2)
+		first, ok := weekdays[bounds[0]]
+		if !ok {
+			return window, invalid
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[bounds[1]]; !ok {
+				return window, invalid
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			window.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	hours := strings.SplitN(fields[1], "-", 2)
+	if len(hours) != 2 {
+		return window, invalid
+	}
+	start, errStart := time.Parse("15:04", hours[0])
+	end, errEnd := time.Parse("15:04", hours[1])
+	if errStart != nil || errEnd != nil {
+		return window, invalid
+	}
+	window.start = start.Hour()*60 + start.Minute()
+	window.end = end.Hour()*60 + end.Minute()
+	if window.end == 0 {
+		// 00:00 as the end is midnight
+		window.end = 24 * 60
+	}
+	return window, nil
+}
+
+// allows tells if destructive actions may run at the time.
+func (s enforcementSchedule) allows(t time.Time) bool {
+	t = t.In(s.location)
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	for _, freeze := range s.freezes {
+		if !day.Before(freeze.from) && !day.After(freeze.to) {
+			return false
+		}
+	}
+
+	if len(s.windows) == 0 {
+		return true
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	weekday := t.Weekday()
+	yesterday := (weekday + 6) % 7
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if w.days[weekday] && minutes >= w.start && minutes < w.end {
+				return true
+			}
+		} else if (w.days[weekday] && minutes >= w.start) || (w.days[yesterday] && minutes < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the first time from t on when destructive actions may run, found is false
+// when the freezes and windows don't allow any in the lookahead.
+func (s enforcementSchedule) next(t time.Time) (time.Time, bool) {
+	if s.allows(t) {
+		return t, true
+	}
+
+	t = t.In(s.location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	for d := 0; d < enforcementLookahead; d++ {
+		day := midnight.AddDate(0, 0, d)
+		candidates := []time.Time{day}
+		for _, w := range s.windows {
+			candidates = append(candidates, day.Add(time.Duration(w.start)*time.Minute))
+		}
+		// the windows are in the order they were configured, not by their start
+		earliest := time.Time{}
+		for _, candidate := range candidates {
+			if candidate.After(t) && s.allows(candidate) && (earliest.IsZero() || candidate.Before(earliest)) {
+				earliest = candidate
+			}
+		}
+		if !earliest.IsZero() {
+			return earliest, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// deferredUntil tells when a remediation that can not run now will run,
+// only destructive remediations are deferred.
+func deferredUntil(remediation Remediation, now time.Time) (string, bool) {
+	if isSupportedRemediation(destructiveRemediations, remediation) == false || schedule.allows(now) {
+		return "", false
+	}
+	next, found := schedule.next(now)
+	if !found {
+		return "after the change freeze", true
+	}
+	return next.Format("Mon 2006-01-02 15:04 MST"), true
+}
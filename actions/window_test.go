@@ -0,0 +1,119 @@
+package actions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEnforcementWindow(t *testing.T) {
+	tests := []struct {
+		entry     string
+		wantDays  []time.Weekday
+		wantStart int
+		wantEnd   int
+		wantErr   bool
+	}{
+		{"Mon-Fri 09:00-17:00", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, 9 * 60, 17 * 60, false},
+		{"fri-mon 22:00-06:00", []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}, 22 * 60, 6 * 60, false},
+		{"Sat,Sun 10:00-00:00", []time.Weekday{time.Saturday, time.Sunday}, 10 * 60, 24 * 60, false},
+		{"Mon 9-17", nil, 0, 0, true},
+		{"Funday 09:00-17:00", nil, 0, 0, true},
+		{"Mon-Fri", nil, 0, 0, true},
+		{"Mon-Fri 09:00", nil, 0, 0, true},
+		{"Mon-Fri 09:00-25:00", nil, 0, 0, true},
+	}
+	for _, test := range tests {
+		window, err := parseEnforcementWindow(test.entry)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseEnforcementWindow(%q) error %v, want an error %t", test.entry, err, test.wantErr)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		wantDays := [7]bool{}
+		for _, d := range test.wantDays {
+			wantDays[d] = true
+		}
+		if window.days != wantDays || window.start != test.wantStart || window.end != test.wantEnd {
+			t.Errorf("parseEnforcementWindow(%q) = %v %d-%d, want %v %d-%d", test.entry, window.days, window.start, window.end, wantDays, test.wantStart, test.wantEnd)
+		}
+	}
+}
+
+func TestEnforcementSchedule(t *testing.T) {
+	previous := schedule
+	defer func() { schedule = previous }()
+
+	// Fri 2026-10-16, a freeze from Thu 2026-12-24 to Mon 2026-12-28
+	err := LoadEnforcementSchedule("Mon-Fri 09:00-17:00;Fri 22:00-06:00;Sat 20:00-00:00", "2026-12-24..2026-12-28", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(date string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	allowsTests := []struct {
+		at   string
+		want bool
+	}{
+		{"2026-10-16 09:00", true},
+		{"2026-10-16 16:59", true},
+		{"2026-10-16 17:00", false},
+		// across midnight
+		{"2026-10-16 23:30", true},
+		{"2026-10-17 05:59", true},
+		{"2026-10-17 06:00", false},
+		{"2026-10-15 23:30", false},
+		// up to midnight
+		{"2026-10-17 23:59", true},
+		{"2026-10-18 00:00", false},
+		// the freeze includes its first and last day
+		{"2026-12-23 16:59", true},
+		{"2026-12-24 09:00", false},
+		{"2026-12-25 23:30", false},
+		{"2026-12-28 16:59", false},
+		{"2026-12-29 09:00", true},
+	}
+	for _, test := range allowsTests {
+		if got := schedule.allows(at(test.at)); got != test.want {
+			t.Errorf("allows(%s) = %t, want %t", test.at, got, test.want)
+		}
+	}
+
+	nextTests := []struct {
+		at   string
+		want string
+	}{
+		{"2026-10-16 10:00", "2026-10-16 10:00"},
+		{"2026-10-16 17:00", "2026-10-16 22:00"},
+		{"2026-10-17 06:00", "2026-10-17 20:00"},
+		{"2026-10-18 00:00", "2026-10-19 09:00"},
+		{"2026-12-23 17:00", "2026-12-29 09:00"},
+	}
+	for _, test := range nextTests {
+		next, found := schedule.next(at(test.at))
+		if !found || !next.Equal(at(test.want)) {
+			t.Errorf("next(%s) = %s, %t, want %s", test.at, next, found, test.want)
+		}
+	}
+
+	err = LoadEnforcementSchedule("", "2026-01-01..2027-12-31", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next, found := schedule.next(at("2026-10-16 10:00")); found {
+		t.Errorf("next in a freeze longer than the lookahead = %s, want none", next)
+	}
+	if until, deferred := deferredUntil(DeleteRemediation, at("2026-10-16 10:00")); !deferred || until != "after the change freeze" {
+		t.Errorf("deferredUntil(delete) = %q, %t, want after the change freeze", until, deferred)
+	}
+	if _, deferred := deferredUntil(AnnotateRemediation, at("2026-10-16 10:00")); deferred {
+		t.Error("deferredUntil(annotate-only) is deferred, only destructive remediations are")
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/k8guard/k8guard-action/actions"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// Serve runs the API until it fails, every request needs one of the user=token bearer tokens.
+//
+//	GET  /pending-actions                 lists the pending actions
+//	POST /pending-actions/<id>/approve    approves one, {"reason": "..."} is optional
+//	POST /pending-actions/<id>/reject     rejects one
+//	GET  /shadow-report?since=&until=     summarizes the shadow log
+//	GET  /rollout                         the rollout percentage and the enforced namespaces
+//	GET  /transitions?namespace=&kind=&name=  the lifecycle transitions of a namespace or one entity
+func Serve(address string, tokens string) error {
+	users, err := parseTokens(tokens)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pending-actions", authenticated(users, listPendingActions))
+	mux.HandleFunc("/pending-actions/", authenticated(users, decidePendingAction))
+	mux.HandleFunc("/shadow-report", authenticated(users, shadowReport))
+	mux.HandleFunc("/rollout", authenticated(users, rollout))
+	mux.HandleFunc("/transitions", authenticated(users, listTransitions))
+
+	libs.Log.Info("Serving the API on ", address)
+	return http.ListenAndServe(address, mux)
+}
+
+// token to user
+func parseTokens(tokens string) (map[string]string, error) {
+	users := map[string]string{}
+	for _, entry := range strings.Split(tokens, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		pair := strings.SplitN(entry, "=", 2)
+		if len(pair) != 2 || len(pair[0]) == 0 || len(pair[1]) == 0 {
+			return nil, errors.New("API tokens must be user=token pairs")
+		}
+		users[pair[1]] = pair[0]
+	}
+	if len(users) == 0 {
+		return nil, errors.New("The approval API needs at least one user=token in K8GUARD_ACTION_API_TOKENS")
+	}
+	return users, nil
+}
+
+// authenticated passes the user of the bearer token to the handler.
+func authenticated(users map[string]string, handler func(w http.ResponseWriter, r *http.Request, user string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for t, user := range users {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				handler(w, r, user)
+				return
+			}
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+}
+
+func listPendingActions(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, actions.ListPendingActions())
+}
+
+func decidePendingAction(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/pending-actions/"), "/")
+	if len(path) != 2 || (path[1] != "approve" && path[1] != "reject") {
+		http.NotFound(w, r)
+		return
+	}
+
+	decision := struct {
+		Reason string `json:"reason"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	pendingAction, err := actions.DecidePendingAction(path[0], path[1] == "approve", user, decision.Reason)
+	if err == actions.ErrNoPendingAction {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, pendingAction)
+}
+
+func shadowReport(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	from, to, err := actions.ParseReportPeriod(r.URL.Query().Get("since"), r.URL.Query().Get("until"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, actions.ShadowReportOf(from, to))
+}
+
+func rollout(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rollout, err := actions.RolloutOf()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, rollout)
+}
+
+func listTransitions(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace, kind, name := r.URL.Query().Get("namespace"), r.URL.Query().Get("kind"), r.URL.Query().Get("name")
+	if len(namespace) == 0 || (len(kind) == 0) != (len(name) == 0) {
+		http.Error(w, "namespace is required, kind and name go together", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, actions.ListTransitions(namespace, kind, name))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		libs.Log.Error(err)
+	}
+}
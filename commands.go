@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/k8guard/k8guard-action/actions"
+	"github.com/k8guard/k8guard-action/config"
+	"github.com/k8guard/k8guard-action/db"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// Operator commands, e.g. k8guard-action restore Deployment my-namespace my-app
+func runCommand(command string, args []string) error {
+	switch command {
+	case "restore":
+		if len(args) != 3 {
+			return errors.New("Usage: k8guard-action restore <Kind> <namespace> <name>")
+		}
+		err := actions.RestoreEntity(args[0], args[1], args[2])
+		if err != nil {
+			return err
+		}
+		libs.Log.Info("Restored ", args[0], " ", args[2], " in namespace ", args[1])
+	case "unfreeze":
+		if len(args) != 1 {
+			return errors.New("Usage: k8guard-action unfreeze <namespace>")
+		}
+		err := actions.RestoreEntity("Namespace", args[0], args[0])
+		if err != nil {
+			return err
+		}
+		libs.Log.Info("Unfroze namespace ", args[0])
+	case "archives":
+		if len(args) != 1 {
+			return errors.New("Usage: k8guard-action archives <namespace>")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "ARCHIVED AT\tKIND\tNAME\tVIOLATION\tREAPPLIED AT")
+		for _, archiveRow := range actions.ListArchivedEntities(args[0]) {
+			reappliedAt := ""
+			if archiveRow.ReappliedAt.IsZero() == false {
+				reappliedAt = archiveRow.ReappliedAt.String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", archiveRow.CreatedAt, archiveRow.Type, archiveRow.Source, archiveRow.VType, reappliedAt)
+		}
+		w.Flush()
+	case "reapply":
+		if len(args) != 3 {
+			return errors.New("Usage: k8guard-action reapply <Kind> <namespace> <name>")
+		}
+		err := actions.ReapplyArchivedEntity(args[0], args[1], args[2])
+		if err != nil {
+			return err
+		}
+		libs.Log.Info("Reapplied ", args[0], " ", args[2], " in namespace ", args[1])
+	case "exempt":
+		exemption, err := parseExemption(command, args, true)
+		if err != nil {
+			return err
+		}
+		err = db.InsertExemptionRow(exemption)
+		if err != nil {
+			return err
+		}
+		libs.Log.Info("Added exemption for ", exemption.Type, " ", exemption.Source, " in namespace ", exemption.Namespace, ", ", exemption)
+	case "unexempt":
+		exemption, err := parseExemption(command, args, false)
+		if err != nil {
+			return err
+		}
+		db.DeleteExemptionRow(exemption)
+		libs.Log.Info("Removed exemption for ", exemption.Type, " ", exemption.Source, " in namespace ", exemption.Namespace)
+	case "exemptions":
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "CLUSTER\tNAMESPACE\tKIND\tNAME\tVIOLATION\tVIOLATION SOURCE\tEXPIRES AT\tCREATED BY\tREASON")
+		for _, e := range db.SelectExemptionRows() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Cluster, e.Namespace, strings.TrimPrefix(e.Type, "Action"), e.Source, e.VType, e.VSource, e.ExpiresAt, e.CreatedBy, e.Reason)
+		}
+		w.Flush()
+	case "offenses":
+		if len(args) != 3 {
+			return errors.New("Usage: k8guard-action offenses <Kind> <namespace> <name>")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "ACTED AT\tREMEDIATION\tVIOLATION\tVIOLATION SOURCE")
+		for _, o := range db.SelectOffenseRows(args[1], "Action"+strings.TrimPrefix(args[0], "Action"), args[2]) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", o.CreatedAt, o.Remediation, o.VType, o.VSource)
+		}
+		w.Flush()
+	case "transitions":
+		if len(args) != 1 && len(args) != 3 {
+			return errors.New("Usage: k8guard-action transitions <namespace> [<Kind> <name>]")
+		}
+		kind, name := "", ""
+		if len(args) == 3 {
+			kind, name = args[1], args[2]
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "AT\tKIND\tNAME\tVIOLATION\tVIOLATION SOURCE\tFROM\tTO\tTRIGGER")
+		for _, t := range actions.ListTransitions(args[0], kind, name) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.CreatedAt, strings.TrimPrefix(t.Type, "Action"), t.Source, t.VType, t.VSource, t.From, t.To, t.Trigger)
+		}
+		w.Flush()
+	case "migrate-states":
+		fmt.Println("Migrated", actions.MigrateViolationStates(), "violations")
+	case "pending":
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tNAMESPACE\tKIND\tNAME\tVIOLATION\tACTION\tEXPIRES AT\tDECIDED BY\tREASON")
+		for _, p := range actions.ListPendingActions() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Status, p.Namespace, strings.TrimPrefix(p.Type, "Action"), p.Source, p.VType, p.Remediation, p.ExpiresAt, p.DecidedBy, p.Reason)
+		}
+		w.Flush()
+	case "approve", "reject":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		decidedBy := flags.String("by", os.Getenv("USER"), "who decides")
+		reason := flags.String("reason", "", "why")
+		err := flags.Parse(args)
+		if err != nil {
+			return err
+		}
+		if flags.NArg() != 1 || len(*decidedBy) == 0 {
+			return errors.New(fmt.Sprintf("Usage: k8guard-action %s [-by <name>] [-reason <reason>] <id>", command))
+		}
+		_, err = actions.DecidePendingAction(flags.Arg(0), command == "approve", *decidedBy, *reason)
+		if err != nil {
+			return err
+		}
+	case "shadow-report":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		since := flags.String("since", "", "start, e.g. 2026-12-01, defaults to a week ago")
+		until := flags.String("until", "", "end, e.g. 2026-12-08, defaults to now")
+		err := flags.Parse(args)
+		if err != nil {
+			return err
+		}
+		from, to, err := actions.ParseReportPeriod(*since, *until)
+		if err != nil {
+			return err
+		}
+		fmt.Print(actions.ShadowReportOf(from, to))
+	case "coverage":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		since := flags.String("since", "", "start, e.g. 2026-12-01, defaults to a week ago")
+		until := flags.String("until", "", "end, e.g. 2026-12-08, defaults to now")
+		err := flags.Parse(args)
+		if err != nil {
+			return err
+		}
+		from, to, err := actions.ParseReportPeriod(*since, *until)
+		if err != nil {
+			return err
+		}
+		coverage := db.SelectScopeCoverage(from, to)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "SCOPE\tNAMESPACE\tENTITIES")
+		for _, scope := range []actions.Scope{actions.ActScope, actions.NotifyScope, actions.LogScope} {
+			for namespace, entities := range coverage[string(scope)] {
+				fmt.Fprintf(w, "%s\t%s\t%d\n", scope, namespace, entities)
+			}
+		}
+		w.Flush()
+	case "rollout":
+		rollout, err := actions.RolloutOf()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Acting on %d%% of the namespaces\n", rollout.Percent)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tBUCKET\tENFORCED")
+		for _, ns := range rollout.Namespaces {
+			fmt.Fprintf(w, "%s\t%d\t%t\n", ns.Namespace, ns.Bucket, ns.Enforced)
+		}
+		w.Flush()
+	case "policies":
+		actionPolicies, namespacePolicies, err := actions.ListPolicyResources()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tVALID\tGOVERNED ENTITIES\tERRORS")
+		for _, p := range actionPolicies {
+			fmt.Fprintf(w, "K8guardActionPolicy\t\t%s\t%t\t%d\t%s\n", p.Name, p.Status.Valid, p.Status.GovernedEntities, strings.Join(p.Status.Errors, "; "))
+		}
+		for _, p := range namespacePolicies {
+			fmt.Fprintf(w, "K8guardNamespacePolicy\t%s\t%s\t%t\t%d\t%s\n", p.Namespace, p.Name, p.Status.Valid, p.Status.GovernedEntities, strings.Join(p.Status.Errors, "; "))
+		}
+		w.Flush()
+	case "breaker":
+		breakerRow := db.SelectBreakerRow()
+		if !breakerRow.Tripped() {
+			fmt.Println("The circuit breaker is not tripped")
+			break
+		}
+		fmt.Println("Tripped at", breakerRow.TrippedAt, "with", breakerRow.Reason)
+		for _, blocked := range breakerRow.Blocked {
+			fmt.Println("  blocked", blocked)
+		}
+	case "reset-breaker":
+		flags := flag.NewFlagSet(command, flag.ContinueOnError)
+		resetBy := flags.String("by", os.Getenv("USER"), "who resets it")
+		err := flags.Parse(args)
+		if err != nil {
+			return err
+		}
+		if len(*resetBy) == 0 {
+			return errors.New("Usage: k8guard-action reset-breaker -by <name>")
+		}
+		db.ResetBreakerRow(*resetBy)
+		libs.Log.Info("Reset the circuit breaker, the running k8guard-action picks it up within ", config.Cfg.BreakerCheckInterval)
+	default:
+		return errors.New(fmt.Sprintf("Unknown command %s", command))
+	}
+	return nil
+}
+
+// parseExemption reads the key of an exemption from the flags, with details also its expiry, reason and creator.
+func parseExemption(command string, args []string, details bool) (db.ExemptionRow, error) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	exemption := db.ExemptionRow{}
+	flags.StringVar(&exemption.Cluster, "cluster", libs.Cfg.ClusterName, "cluster, * for every cluster")
+	flags.StringVar(&exemption.Namespace, "namespace", db.ExemptionWildcard, "namespace, may contain * wildcards")
+	flags.StringVar(&exemption.Type, "kind", db.ExemptionWildcard, "kind, e.g. Deployment")
+	flags.StringVar(&exemption.Source, "name", db.ExemptionWildcard, "name of the entity, may contain * wildcards")
+	flags.StringVar(&exemption.VType, "violation-type", db.ExemptionWildcard, "violation type, e.g. PRIVILEGED")
+	flags.StringVar(&exemption.VSource, "violation-source", db.ExemptionWildcard, "violation source, may contain * wildcards")
+	until := ""
+	if details {
+		flags.StringVar(&until, "until", "", "expiry, e.g. 2026-12-01 or 2026-12-01T12:00:00Z")
+		flags.StringVar(&exemption.Reason, "reason", "", "why it is exempted, e.g. a ticket")
+		flags.StringVar(&exemption.CreatedBy, "by", os.Getenv("USER"), "who exempts it")
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return exemption, err
+	}
+
+	if exemption.Type != db.ExemptionWildcard {
+		exemption.Type = "Action" + strings.TrimPrefix(exemption.Type, "Action")
+	}
+	if !details {
+		return exemption, nil
+	}
+
+	if len(exemption.Reason) == 0 || len(exemption.CreatedBy) == 0 {
+		return exemption, errors.New("An exemption needs a -reason and a -by")
+	}
+	exemption.ExpiresAt, err = time.Parse("2006-01-02", until)
+	if err != nil {
+		exemption.ExpiresAt, err = time.Parse(time.RFC3339, until)
+	}
+	if err != nil {
+		return exemption, fmt.Errorf("Invalid -until %q, use 2006-01-02 or RFC3339", until)
+	}
+	return exemption, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// Config holds the settings that only k8guard-action uses,
+// the settings shared with the other k8guard services live in libs.Cfg.
+type Config struct {
+	// Comma separated list of Kind[:ViolationType]=remediation, for example
+	// "Deployment=delete,Deployment:PRIVILEGED=label-isolate,Pod=notify-only".
+	// Kinds that are not listed keep their default remediation.
+	Remediations string `env:"K8GUARD_ACTION_REMEDIATIONS"`
+	// Path of the YAML or JSON escalation policy file, see actions.EscalationPolicy.
+	EscalationPolicyFile string `env:"K8GUARD_ACTION_ESCALATION_POLICY_FILE"`
+	// Path of the YAML or JSON file that scopes enforcement to some namespaces and entities, see actions.ScopeFile.
+	ScopeFile string `env:"K8GUARD_ACTION_SCOPE_FILE"`
+	// Percentage of the namespaces that are acted on, picked by a stable hash of their names. The others are in safe mode.
+	RolloutPercent int `env:"K8GUARD_ACTION_ROLLOUT_PERCENT" envDefault:"100"`
+	// A .rego file or a directory of them that decides about each violation before it escalates, empty means none.
+	DecisionPolicyPath string `env:"K8GUARD_ACTION_DECISION_POLICY_PATH"`
+	// The Rego query that returns {"decision": "escalate|notify|act|skip", "reason": "..."}.
+	DecisionQuery string `env:"K8GUARD_ACTION_DECISION_QUERY" envDefault:"data.k8guard.decision"`
+	// How often the decision policy files are checked for changes.
+	DecisionPolicyReloadInterval time.Duration `env:"K8GUARD_ACTION_DECISION_POLICY_RELOAD_INTERVAL" envDefault:"30s"`
+	// How long an action on an entity counts toward the repeat offender steps of the escalation policy.
+	OffenseRetention time.Duration `env:"K8GUARD_ACTION_OFFENSE_RETENTION" envDefault:"2160h"`
+	// How often the K8guardActionPolicy and K8guardNamespacePolicy resources are applied and their status is written.
+	PolicyResourceSyncInterval time.Duration `env:"K8GUARD_ACTION_POLICY_RESOURCE_SYNC_INTERVAL" envDefault:"30s"`
+	// How often the exemptions of the exempt command are read from the db.
+	ExemptionSyncInterval time.Duration `env:"K8GUARD_ACTION_EXEMPTION_SYNC_INTERVAL" envDefault:"30s"`
+	// Weekly windows in which destructive actions run, e.g. "Mon-Fri 09:00-17:00;Sat 10:00-12:00", empty means always.
+	EnforcementWindows string `env:"K8GUARD_ACTION_ENFORCEMENT_WINDOWS"`
+	// Days on which destructive actions never run, e.g. "2026-12-20..2027-01-03;2026-11-26".
+	ChangeFreezes string `env:"K8GUARD_ACTION_CHANGE_FREEZES"`
+	// Timezone of the enforcement windows and change freezes.
+	EnforcementTimezone string `env:"K8GUARD_ACTION_ENFORCEMENT_TIMEZONE" envDefault:"UTC"`
+	// How long after being created an entity is only informed about violations, 0 means no grace period.
+	GracePeriod time.Duration `env:"K8GUARD_ACTION_GRACE_PERIOD" envDefault:"0s"`
+	// Whether the freeze remediation also scales the Deployments and StatefulSets of the namespace to zero.
+	FreezeScalesWorkloads bool `env:"K8GUARD_ACTION_FREEZE_SCALE_WORKLOADS" envDefault:"false"`
+	// How often quarantine network policies are checked and removed once their violation cleared.
+	QuarantineSweepInterval time.Duration `env:"K8GUARD_ACTION_QUARANTINE_SWEEP_INTERVAL" envDefault:"5m"`
+	// How often violations that are no longer reported are resolved once their row expired.
+	ExpirySweepInterval time.Duration `env:"K8GUARD_ACTION_EXPIRY_SWEEP_INTERVAL" envDefault:"5m"`
+	// Ceilings of destructive actions, exceeding one trips the circuit breaker until it is reset. 0 means no ceiling.
+	MaxActionsPerMinute    int `env:"K8GUARD_ACTION_MAX_ACTIONS_PER_MINUTE" envDefault:"0"`
+	MaxActionsPerHour      int `env:"K8GUARD_ACTION_MAX_ACTIONS_PER_HOUR" envDefault:"0"`
+	MaxActionsPerNamespace int `env:"K8GUARD_ACTION_MAX_ACTIONS_PER_NAMESPACE" envDefault:"0"`
+	// How often a reset of the circuit breaker is picked up and newly blocked actions are alerted.
+	BreakerCheckInterval time.Duration `env:"K8GUARD_ACTION_BREAKER_CHECK_INTERVAL" envDefault:"1m"`
+	// How long a destructive action waits for approval in namespaces that require it, a rejection holds as long.
+	ApprovalTimeout time.Duration `env:"K8GUARD_ACTION_APPROVAL_TIMEOUT" envDefault:"24h"`
+	// Address the approval API listens on, e.g. ":8080", empty disables it.
+	APIAddress string `env:"K8GUARD_ACTION_API_ADDRESS"`
+	// Comma separated user=token pairs, the user of the bearer token is recorded as the approver.
+	APITokens string `env:"K8GUARD_ACTION_API_TOKENS"`
+	// Slack channel that gets the would-be notifications in shadow mode, empty sends none.
+	ShadowSlackChannel string `env:"K8GUARD_ACTION_SHADOW_SLACK_CHANNEL"`
+	// How long the shadow log is kept.
+	ShadowLogRetention time.Duration `env:"K8GUARD_ACTION_SHADOW_LOG_RETENTION" envDefault:"720h"`
+	// Client side rate limit of the shared Kubernetes clientset.
+	KubeQPS   float32 `env:"K8GUARD_ACTION_KUBE_QPS" envDefault:"20"`
+	KubeBurst int     `env:"K8GUARD_ACTION_KUBE_BURST" envDefault:"30"`
+	// Base64 AES key the data of the secrets archived with a namespace is sealed with, empty archives them without data.
+	ArchiveSecretKey string `env:"K8GUARD_ACTION_ARCHIVE_SECRET_KEY"`
+	// How often the informer cache of namespaces and pod owners is fully resynced.
+	CacheResyncInterval time.Duration `env:"K8GUARD_ACTION_CACHE_RESYNC_INTERVAL" envDefault:"10m"`
+}
+
+var Cfg Config
+
+func init() {
+	Cfg = Config{}
+	err := env.Parse(&Cfg)
+	if err != nil {
+		libs.Log.Error(err)
+	}
+}
@@ -12,22 +12,24 @@ import (
 	"github.com/k8guard/k8guardlibs/violations"
 )
 
-func InsertVLOGRow(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) {
-	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_VLOG, libs.Cfg.CassandraKeyspace), vEntity.Namespace, libs.Cfg.ClusterName, entityType, vEntity.Name, string(violation.Type), violation.Source, time.Now()).Exec()
+// exemption describes the exemption the violation matched, empty if it is not exempted.
+func InsertVLOGRow(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string, severity string, exemption string) {
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_VLOG, libs.Cfg.CassandraKeyspace), vEntity.Namespace, libs.Cfg.ClusterName, entityType, vEntity.Name, string(violation.Type), violation.Source, severity, exemption, time.Now()).Exec()
 	if err != nil {
 		panic(err)
 	}
 }
 
-func InsertActionLogRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, action string) {
+// reason is why the decision policy decided on the action, empty without one.
+func InsertActionLogRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, severity string, action string, status string, detail string, reason string) {
 	b := Sess.NewBatch(gocql.LoggedBatch)
 
 	now := time.Now()
 
-	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_NAMESPACE_TYPE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, action, now)
-	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_TYPE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, action, now)
-	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_VTYPE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, action, now)
-	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_ACTION, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, action, now)
+	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_NAMESPACE_TYPE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, severity, action, status, detail, reason, now)
+	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_TYPE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, severity, action, status, detail, reason, now)
+	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_VTYPE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, severity, action, status, detail, reason, now)
+	b.Query(fmt.Sprintf(stmts.INSERT_TO_ALOG_ACTION, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, severity, action, status, detail, reason, now)
 
 	err := Sess.ExecuteBatch(b)
 	if err != nil {
@@ -36,6 +38,15 @@ func InsertActionLogRow(namespace string, entityType string, entitySource string
 }
 
 func SelectVActionRow(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) VActionRow {
+	return selectVActionRow(stmts.SELECT_ENTITY_FROM_VACTION, vEntity, violation, entityType)
+}
+
+// The status of the violation in shadow mode, kept apart so enforcing later starts from scratch.
+func SelectShadowVActionRow(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) VActionRow {
+	return selectVActionRow(stmts.SELECT_ENTITY_FROM_SHADOW_VACTION, vEntity, violation, entityType)
+}
+
+func selectVActionRow(stmt string, vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) VActionRow {
 	vActionQuery := VActionRow{
 		Namespace: vEntity.Namespace,
 		Type:      entityType,
@@ -44,13 +55,13 @@ func SelectVActionRow(vEntity libs.ViolatableEntity, violation violations.Violat
 		VSource:   violation.Source,
 	}
 
-	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_ENTITY_FROM_VACTION, libs.Cfg.CassandraKeyspace),
+	iter := Sess.Query(fmt.Sprintf(stmt, libs.Cfg.CassandraKeyspace),
 		vActionQuery.Namespace, libs.Cfg.ClusterName, vActionQuery.Type, vActionQuery.Source, vActionQuery.VType, vActionQuery.VSource).Iter()
 
 	vActionRow := VActionRow{Actions: map[string][]time.Time{}}
 
 	for iter.Scan(&vActionRow.Namespace, &vActionRow.Type, &vActionRow.Source, &vActionRow.VType,
-		&vActionRow.VSource, &vActionRow.Actions, &vActionRow.CreatedAt, &vActionRow.ExpiresAt) {
+		&vActionRow.VSource, &vActionRow.Actions, &vActionRow.CreatedAt, &vActionRow.ExpiresAt, &vActionRow.State, &vActionRow.StateAt) {
 		break
 	}
 
@@ -61,15 +72,29 @@ func SelectVActionRow(vEntity libs.ViolatableEntity, violation violations.Violat
 	if vActionRow.ExpiresAt.IsZero() == false {
 		//If it is expired create a new row with new data
 		if vActionRow.ExpiresAt.Before(time.Now()) {
-			vActionRow = VActionRow{Actions: map[string][]time.Time{}}
+			vActionRow = VActionRow{Actions: map[string][]time.Time{}, ExpiredState: vActionRow.State, ExpiredAt: vActionRow.ExpiresAt}
 		}
 	}
 
 	return vActionRow
 }
 
-func InsertVactionRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, actions map[string][]time.Time) {
-	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_VACTION, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, actions, time.Now(), time.Now().Add(libs.Cfg.DurationViolationExpires)).Exec()
+// state is the lifecycle state the violation got into at stateAt.
+func InsertVactionRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, actions map[string][]time.Time, state string, stateAt time.Time) {
+	insertVactionRow(stmts.INSERT_TO_VACTION, namespace, entityType, entitySource, violationType, violationSource, actions, state, stateAt, time.Now().Add(libs.Cfg.DurationViolationExpires))
+}
+
+func InsertShadowVactionRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, actions map[string][]time.Time, state string, stateAt time.Time) {
+	insertVactionRow(stmts.INSERT_TO_SHADOW_VACTION, namespace, entityType, entitySource, violationType, violationSource, actions, state, stateAt, time.Now().Add(libs.Cfg.DurationViolationExpires))
+}
+
+// ExpireVactionRow ends the violation in the state at at, e.g. resolved, so its next row starts from scratch.
+func ExpireVactionRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, state string, at time.Time) {
+	insertVactionRow(stmts.INSERT_TO_VACTION, namespace, entityType, entitySource, violationType, violationSource, map[string][]time.Time{}, state, at, at)
+}
+
+func insertVactionRow(stmt string, namespace string, entityType string, entitySource string, violationType string, violationSource string, actions map[string][]time.Time, state string, stateAt time.Time, expiresAt time.Time) {
+	err := Sess.Query(fmt.Sprintf(stmt, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, actions, time.Now(), expiresAt, state, stateAt).Exec()
 	if err != nil {
 		panic(err)
 	}
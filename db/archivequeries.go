@@ -0,0 +1,84 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+func InsertArchiveRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, manifest []byte, createdAt time.Time) {
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_ARCHIVE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, string(manifest), createdAt).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// One object archived with an entity, archivedAt is the created_at of the archive row of the entity.
+func InsertArchiveContentRow(namespace string, entityType string, entitySource string, archivedAt time.Time, kind string, name string, manifest []byte) {
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_ARCHIVE_CONTENT, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, archivedAt, kind, name, string(manifest)).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the objects archived with an entity keyed by Kind/name.
+func SelectArchiveContentRows(namespace string, entityType string, entitySource string, archivedAt time.Time) map[string][]byte {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_ARCHIVE_CONTENT, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, archivedAt).Iter()
+
+	contents := map[string][]byte{}
+	var kind, name, manifest string
+	for iter.Scan(&kind, &name, &manifest) {
+		contents[kind+"/"+name] = []byte(manifest)
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return contents
+}
+
+// Lists the archived entities of a namespace without their manifests.
+func SelectArchiveRows(namespace string) []ArchiveRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_ARCHIVES_IN_NAMESPACE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName).Iter()
+
+	archiveRows := []ArchiveRow{}
+	archiveRow := ArchiveRow{}
+	for iter.Scan(&archiveRow.Namespace, &archiveRow.Type, &archiveRow.Source, &archiveRow.VType, &archiveRow.VSource,
+		&archiveRow.CreatedAt, &archiveRow.ReappliedAt) {
+		archiveRows = append(archiveRows, archiveRow)
+		archiveRow = ArchiveRow{}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return archiveRows
+}
+
+// Returns the last archived manifest of an entity, found is false if it was never archived.
+func SelectLatestArchiveRow(namespace string, entityType string, entitySource string) (ArchiveRow, bool) {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_LATEST_ARCHIVE, libs.Cfg.CassandraKeyspace),
+		namespace, libs.Cfg.ClusterName, entityType, entitySource).Iter()
+
+	archiveRow := ArchiveRow{}
+	found := iter.Scan(&archiveRow.Namespace, &archiveRow.Type, &archiveRow.Source, &archiveRow.VType, &archiveRow.VSource,
+		&archiveRow.Manifest, &archiveRow.CreatedAt, &archiveRow.ReappliedAt)
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return archiveRow, found
+}
+
+func MarkArchiveRowReapplied(archiveRow ArchiveRow) {
+	err := Sess.Query(fmt.Sprintf(stmts.UPDATE_ARCHIVE_REAPPLIED, libs.Cfg.CassandraKeyspace), time.Now(), archiveRow.Namespace, libs.Cfg.ClusterName, archiveRow.Type, archiveRow.Source, archiveRow.CreatedAt).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+func TripBreakerRow(reason string, at time.Time) {
+	err := Sess.Query(fmt.Sprintf(stmts.TRIP_BREAKER, libs.Cfg.CassandraKeyspace), at, reason, libs.Cfg.ClusterName).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Adds an action to the ones blocked by the tripped breaker, an action that is already there is kept once.
+func AddBreakerBlocked(action string) {
+	err := Sess.Query(fmt.Sprintf(stmts.ADD_BREAKER_BLOCKED, libs.Cfg.CassandraKeyspace), []string{action}, libs.Cfg.ClusterName).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func ResetBreakerRow(resetBy string) {
+	err := Sess.Query(fmt.Sprintf(stmts.RESET_BREAKER, libs.Cfg.CassandraKeyspace), time.Now(), resetBy, libs.Cfg.ClusterName).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the circuit breaker of this cluster, it is not tripped if it never was.
+func SelectBreakerRow() BreakerRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_BREAKER, libs.Cfg.CassandraKeyspace), libs.Cfg.ClusterName).Iter()
+
+	breakerRow := BreakerRow{}
+	iter.Scan(&breakerRow.TrippedAt, &breakerRow.Reason, &breakerRow.Blocked, &breakerRow.ResetAt, &breakerRow.ResetBy)
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return breakerRow
+}
+
+func (b BreakerRow) Tripped() bool {
+	return b.TrippedAt.IsZero() == false
+}
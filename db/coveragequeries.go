@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// Counts the entity in the scope once a day, days of the coverage are partitions, in UTC like the shadow log.
+func IncrementScopeCoverage(scope string, namespace string, entityType string, entitySource string) {
+	day := time.Now().UTC().Format(shadowLogDay)
+	applied, err := Sess.Query(fmt.Sprintf(stmts.INSERT_SCOPE_COVERAGE_ENTITY, libs.Cfg.CassandraKeyspace),
+		libs.Cfg.ClusterName, day, scope, namespace, entityType, entitySource).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		panic(err)
+	}
+	if !applied {
+		// already counted today
+		return
+	}
+
+	err = Sess.Query(fmt.Sprintf(stmts.INCREMENT_SCOPE_COVERAGE, libs.Cfg.CassandraKeyspace),
+		libs.Cfg.ClusterName, day, scope, namespace).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the entities counted in each scope and namespace on the days from from until to, keyed by scope and namespace.
+func SelectScopeCoverage(from time.Time, to time.Time) map[string]map[string]int64 {
+	coverage := map[string]map[string]int64{}
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.Add(24 * time.Hour) {
+		iter := Sess.Query(fmt.Sprintf(stmts.SELECT_SCOPE_COVERAGE_OF_DAY, libs.Cfg.CassandraKeyspace), libs.Cfg.ClusterName, day.Format(shadowLogDay)).Iter()
+
+		var scope, namespace string
+		var entities int64
+		for iter.Scan(&scope, &namespace, &entities) {
+			if _, ok := coverage[scope]; !ok {
+				coverage[scope] = map[string]int64{}
+			}
+			coverage[scope][namespace] += entities
+		}
+
+		if err := iter.Close(); err != nil {
+			panic(err)
+		}
+	}
+	return coverage
+}
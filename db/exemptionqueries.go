@@ -0,0 +1,95 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"github.com/k8guard/k8guardlibs/violations"
+)
+
+// Matches any value in a key column of an exemption.
+const ExemptionWildcard = "*"
+
+// Rows expire from the table with the exemption, that is never later than the expiry passed in.
+func InsertExemptionRow(exemption ExemptionRow) error {
+	ttl := int(exemption.ExpiresAt.Sub(time.Now()).Seconds())
+	if ttl <= 0 {
+		return errors.New("The exemption expires in the past")
+	}
+	return Sess.Query(fmt.Sprintf(stmts.INSERT_TO_EXEMPTION, libs.Cfg.CassandraKeyspace),
+		exemption.Namespace, exemption.Cluster, exemption.Type, exemption.Source, exemption.VType, exemption.VSource,
+		exemption.Reason, exemption.CreatedBy, time.Now(), exemption.ExpiresAt, ttl).Exec()
+}
+
+func DeleteExemptionRow(exemption ExemptionRow) {
+	err := Sess.Query(fmt.Sprintf(stmts.DELETE_EXEMPTION, libs.Cfg.CassandraKeyspace),
+		exemption.Cluster, exemption.Namespace, exemption.Type, exemption.Source, exemption.VType, exemption.VSource).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the exemptions of this cluster and the ones for every cluster that did not expire yet.
+func SelectExemptionRows() []ExemptionRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_EXEMPTIONS, libs.Cfg.CassandraKeyspace),
+		[]string{libs.Cfg.ClusterName, ExemptionWildcard}).Iter()
+
+	exemptionRows := []ExemptionRow{}
+	exemptionRow := ExemptionRow{}
+	for iter.Scan(&exemptionRow.Namespace, &exemptionRow.Cluster, &exemptionRow.Type, &exemptionRow.Source, &exemptionRow.VType,
+		&exemptionRow.VSource, &exemptionRow.Reason, &exemptionRow.CreatedBy, &exemptionRow.CreatedAt, &exemptionRow.ExpiresAt) {
+		if exemptionRow.ExpiresAt.After(time.Now()) {
+			exemptionRows = append(exemptionRows, exemptionRow)
+		}
+		exemptionRow = ExemptionRow{}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return exemptionRows
+}
+
+// Matches tells if the exemption applies to the violation, whether it expired is not checked.
+func (e ExemptionRow) Matches(vEntity libs.ViolatableEntity, violation violations.Violation, entityType string) bool {
+	return matchesWildcard(e.Namespace, vEntity.Namespace) &&
+		matchesWildcard(e.Type, entityType) &&
+		matchesWildcard(e.Source, vEntity.Name) &&
+		matchesWildcard(e.VType, string(violation.Type)) &&
+		matchesWildcard(e.VSource, violation.Source)
+}
+
+// Describes the exemption for the logs, the ones of policy resources may not expire.
+func (e ExemptionRow) String() string {
+	if e.ExpiresAt.IsZero() {
+		return fmt.Sprintf("exempted by %s: %s", e.CreatedBy, e.Reason)
+	}
+	return fmt.Sprintf("exempted until %s by %s: %s", e.ExpiresAt.Format(time.RFC3339), e.CreatedBy, e.Reason)
+}
+
+// * matches any run of characters, e.g. team-* or *.example.com
+func matchesWildcard(pattern string, value string) bool {
+	parts := strings.Split(pattern, ExemptionWildcard)
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/k8guard/k8guard-action/db/stmts"
 
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gocql/gocql"
@@ -79,10 +80,32 @@ func initDB() error {
 		if err != nil {
 			return err
 		}
+		// violations tracked before they had a lifecycle state, see MigrateVactionStates
+		err = addColumn("vaction", "state", "varchar")
+		if err != nil {
+			return err
+		}
+		err = addColumn("vaction", "state_at", "timestamp")
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_TRANSITION_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
 		err = Sess.Query(fmt.Sprintf(stmts.CREATE_VIOLATION_LOG_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
 		if err != nil {
 			return err
 		}
+		// violation logs created before exemptions and severities existed
+		err = addColumn("vlog_namespace_type", "exemption", "text")
+		if err != nil {
+			return err
+		}
+		err = addColumn("vlog_namespace_type", "severity", "varchar")
+		if err != nil {
+			return err
+		}
 		err = Sess.Query(fmt.Sprintf(stmts.CREATE_ACTION_LOG_NAMESPACE_TYPE_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
 		if err != nil {
 			return err
@@ -99,8 +122,92 @@ func initDB() error {
 		if err != nil {
 			return err
 		}
+		// action logs created before the status, detail and severity columns existed
+		for _, table := range []string{"alog_namespace_type", "alog_type", "alog_vType", "alog_action"} {
+			err = addColumn(table, "status", "varchar")
+			if err != nil {
+				return err
+			}
+			err = addColumn(table, "detail", "text")
+			if err != nil {
+				return err
+			}
+			err = addColumn(table, "severity", "varchar")
+			if err != nil {
+				return err
+			}
+			err = addColumn(table, "reason", "text")
+			if err != nil {
+				return err
+			}
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_ENTITY_STATE_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_ARCHIVE_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_ARCHIVE_CONTENT_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_EXEMPTION_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_BREAKER_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_PENDING_ACTION_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_SHADOW_VACTION_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = addColumn("vaction_shadow", "state", "varchar")
+		if err != nil {
+			return err
+		}
+		err = addColumn("vaction_shadow", "state_at", "timestamp")
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_SHADOW_LOG_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = addColumn("slog", "reason", "text")
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_SCOPE_COVERAGE_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_SCOPE_COVERAGE_ENTITY_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
+		err = Sess.Query(fmt.Sprintf(stmts.CREATE_OFFENSE_TABLE, libs.Cfg.CassandraKeyspace)).Exec()
+		if err != nil {
+			return err
+		}
 	} else {
 		libs.Log.Info("Skipping creating tables")
 	}
 	return nil
 }
+
+// Adds a column to an existing table, a column that is already there is not an error.
+func addColumn(table string, column string, columnType string) error {
+	err := Sess.Query(fmt.Sprintf(stmts.ADD_COLUMN, libs.Cfg.CassandraKeyspace, table, column, columnType)).Exec()
+	if err != nil && strings.Contains(err.Error(), "conflicts with an existing column") == false {
+		return err
+	}
+	return nil
+}
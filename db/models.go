@@ -13,4 +13,110 @@ type VActionRow struct {
 	Actions   map[string][]time.Time
 	CreatedAt time.Time
 	ExpiresAt time.Time
+	// lifecycle state and when the violation got into it, empty for rows written before states existed
+	State   string
+	StateAt time.Time
+	// set when the last row of the violation expired, the row is then new
+	ExpiredState string
+	ExpiredAt    time.Time
+}
+
+// A change of the lifecycle state of a violation.
+type TransitionRow struct {
+	Namespace string
+	Type      string
+	Source    string
+	VType     string
+	VSource   string
+	From      string
+	To        string
+	// the done action or event that caused it
+	Trigger   string
+	CreatedAt time.Time
+}
+
+// An action taken on an entity.
+type OffenseRow struct {
+	Namespace   string
+	Type        string
+	Source      string
+	VType       string
+	VSource     string
+	Remediation string
+	CreatedAt   time.Time
+}
+
+type EntityStateRow struct {
+	Namespace   string
+	Type        string
+	Source      string
+	VType       string
+	VSource     string
+	Remediation string
+	State       map[string]string
+	CreatedAt   time.Time
+	RestoredAt  time.Time
+}
+
+type ArchiveRow struct {
+	Namespace   string
+	Type        string
+	Source      string
+	VType       string
+	VSource     string
+	Manifest    string
+	CreatedAt   time.Time
+	ReappliedAt time.Time
+}
+
+type ExemptionRow struct {
+	Namespace string
+	Cluster   string
+	Type      string
+	Source    string
+	VType     string
+	VSource   string
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type BreakerRow struct {
+	TrippedAt time.Time
+	Reason    string
+	Blocked   []string
+	ResetAt   time.Time
+	ResetBy   string
+}
+
+type PendingActionRow struct {
+	ID          string
+	Namespace   string
+	Type        string
+	Source      string
+	VType       string
+	VSource     string
+	Severity    string
+	Remediation string
+	Status      string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	DecidedBy   string
+	DecidedAt   time.Time
+	Reason      string
+}
+
+type ShadowLogRow struct {
+	Namespace string
+	Type      string
+	Source    string
+	VType     string
+	VSource   string
+	Severity  string
+	Action    string
+	Status    string
+	Detail    string
+	Reason    string
+	CreatedAt time.Time
 }
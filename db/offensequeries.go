@@ -0,0 +1,37 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// Offenses expire after the retention, so an entity that behaves long enough is forgiven.
+func InsertOffenseRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, remediation string, retention time.Duration) {
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_OFFENSE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource,
+		violationType, violationSource, remediation, time.Now(), int(retention.Seconds())).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the offenses of an entity for every violation, the latest first.
+func SelectOffenseRows(namespace string, entityType string, entitySource string) []OffenseRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_OFFENSES, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource).Iter()
+
+	offenseRows := []OffenseRow{}
+	offenseRow := OffenseRow{}
+	for iter.Scan(&offenseRow.Namespace, &offenseRow.Type, &offenseRow.Source, &offenseRow.VType, &offenseRow.VSource,
+		&offenseRow.Remediation, &offenseRow.CreatedAt) {
+		offenseRows = append(offenseRows, offenseRow)
+		offenseRow = OffenseRow{}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+	return offenseRows
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	"github.com/gocql/gocql"
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// How long pending actions are kept after they were requested, for the record.
+const pendingActionRetention = 7 * 24 * time.Hour
+
+// Returns the id of the new pending action.
+func InsertPendingActionRow(pendingAction PendingActionRow) string {
+	id := gocql.TimeUUID().String()
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_PENDING_ACTION, libs.Cfg.CassandraKeyspace),
+		libs.Cfg.ClusterName, id, pendingAction.Namespace, pendingAction.Type, pendingAction.Source, pendingAction.VType, pendingAction.VSource,
+		pendingAction.Severity, pendingAction.Remediation, pendingAction.Status, pendingAction.CreatedAt, pendingAction.ExpiresAt,
+		pendingActionTTL(pendingAction)).Exec()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// Updates the status, expiry and decision of a pending action.
+func UpdatePendingActionRow(pendingAction PendingActionRow) {
+	err := Sess.Query(fmt.Sprintf(stmts.UPDATE_PENDING_ACTION, libs.Cfg.CassandraKeyspace),
+		pendingActionTTL(pendingAction), pendingAction.Status, pendingAction.ExpiresAt, pendingAction.DecidedBy, pendingAction.DecidedAt,
+		pendingAction.Reason, libs.Cfg.ClusterName, pendingAction.ID).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the pending actions of this cluster, the latest first.
+func SelectPendingActionRows() []PendingActionRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_PENDING_ACTIONS, libs.Cfg.CassandraKeyspace), libs.Cfg.ClusterName).Iter()
+
+	pendingActionRows := []PendingActionRow{}
+	pendingActionRow := PendingActionRow{}
+	for scanPendingActionRow(iter, &pendingActionRow) {
+		pendingActionRows = append(pendingActionRows, pendingActionRow)
+		pendingActionRow = PendingActionRow{}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return pendingActionRows
+}
+
+// Returns the pending action with the id, found is false if there is none or the id is not a uuid.
+func SelectPendingActionRow(id string) (PendingActionRow, bool) {
+	if _, err := gocql.ParseUUID(id); err != nil {
+		return PendingActionRow{}, false
+	}
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_PENDING_ACTION, libs.Cfg.CassandraKeyspace), libs.Cfg.ClusterName, id).Iter()
+
+	pendingActionRow := PendingActionRow{}
+	found := scanPendingActionRow(iter, &pendingActionRow)
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return pendingActionRow, found
+}
+
+// Returns the latest pending action for the violation, found is false if none was requested.
+func SelectLatestPendingActionRow(namespace string, entityType string, entitySource string, violationType string, violationSource string) (PendingActionRow, bool) {
+	for _, pendingActionRow := range SelectPendingActionRows() {
+		if pendingActionRow.Namespace == namespace && pendingActionRow.Type == entityType && pendingActionRow.Source == entitySource &&
+			pendingActionRow.VType == violationType && pendingActionRow.VSource == violationSource {
+			return pendingActionRow, true
+		}
+	}
+	return PendingActionRow{}, false
+}
+
+func scanPendingActionRow(iter *gocql.Iter, p *PendingActionRow) bool {
+	return iter.Scan(&p.ID, &p.Namespace, &p.Type, &p.Source, &p.VType, &p.VSource, &p.Severity, &p.Remediation,
+		&p.Status, &p.CreatedAt, &p.ExpiresAt, &p.DecidedBy, &p.DecidedAt, &p.Reason)
+}
+
+// the row is kept for the retention after it was requested
+func pendingActionTTL(pendingAction PendingActionRow) int {
+	ttl := int(pendingAction.CreatedAt.Add(pendingActionRetention).Sub(time.Now()).Seconds())
+	if ttl <= 0 {
+		return 1
+	}
+	return ttl
+}
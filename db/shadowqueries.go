@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	"github.com/gocql/gocql"
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+// Days of the shadow log are partitions, in UTC.
+const shadowLogDay = "2006-01-02"
+
+// Like InsertActionLogRow for shadow mode, the row expires after the retention.
+func InsertShadowLogRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, severity string, action string, status string, detail string, reason string, retention time.Duration) {
+	now := time.Now()
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_SHADOW_LOG, libs.Cfg.CassandraKeyspace), libs.Cfg.ClusterName, now.UTC().Format(shadowLogDay), gocql.TimeUUID(),
+		namespace, entityType, entitySource, violationType, violationSource, severity, action, status, detail, reason, now, int(retention.Seconds())).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the shadow log of this cluster from from until to.
+func SelectShadowLogRows(from time.Time, to time.Time) []ShadowLogRow {
+	shadowLogRows := []ShadowLogRow{}
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.Add(24 * time.Hour) {
+		iter := Sess.Query(fmt.Sprintf(stmts.SELECT_SHADOW_LOG_OF_DAY, libs.Cfg.CassandraKeyspace), libs.Cfg.ClusterName, day.Format(shadowLogDay)).Iter()
+
+		shadowLogRow := ShadowLogRow{}
+		for iter.Scan(&shadowLogRow.Namespace, &shadowLogRow.Type, &shadowLogRow.Source, &shadowLogRow.VType, &shadowLogRow.VSource,
+			&shadowLogRow.Severity, &shadowLogRow.Action, &shadowLogRow.Status, &shadowLogRow.Detail, &shadowLogRow.Reason, &shadowLogRow.CreatedAt) {
+			if !shadowLogRow.CreatedAt.Before(from) && shadowLogRow.CreatedAt.Before(to) {
+				shadowLogRows = append(shadowLogRows, shadowLogRow)
+			}
+			shadowLogRow = ShadowLogRow{}
+		}
+
+		if err := iter.Close(); err != nil {
+			panic(err)
+		}
+	}
+	return shadowLogRows
+}
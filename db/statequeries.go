@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+func InsertEntityStateRow(namespace string, entityType string, entitySource string, violationType string, violationSource string, remediation string, state map[string]string) {
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_ENTITY_STATE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource, violationType, violationSource, remediation, state, time.Now()).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the recorded states of an entity that were not restored yet, the latest first.
+func SelectUnrestoredEntityStateRows(namespace string, entityType string, entitySource string) []EntityStateRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_ENTITY_STATES, libs.Cfg.CassandraKeyspace),
+		namespace, libs.Cfg.ClusterName, entityType, entitySource).Iter()
+
+	stateRows := []EntityStateRow{}
+	stateRow := EntityStateRow{State: map[string]string{}}
+	for iter.Scan(&stateRow.Namespace, &stateRow.Type, &stateRow.Source, &stateRow.VType, &stateRow.VSource,
+		&stateRow.Remediation, &stateRow.State, &stateRow.CreatedAt, &stateRow.RestoredAt) {
+		if stateRow.RestoredAt.IsZero() {
+			stateRows = append(stateRows, stateRow)
+		}
+		stateRow = EntityStateRow{State: map[string]string{}}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+
+	return stateRows
+}
+
+func MarkEntityStateRowRestored(stateRow EntityStateRow) {
+	err := Sess.Query(fmt.Sprintf(stmts.UPDATE_ENTITY_STATE_RESTORED, libs.Cfg.CassandraKeyspace), time.Now(), stateRow.Namespace, libs.Cfg.ClusterName, stateRow.Type, stateRow.Source, stateRow.CreatedAt).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
@@ -22,6 +22,8 @@ const (
 			source varchar,
 			vType varchar,
 			vSource varchar,
+			severity varchar,
+			exemption text,
 			created_at timestamp,
 			PRIMARY KEY((namespace,cluster,type,source),created_at))
 			WITH CLUSTERING ORDER BY (created_at DESC)
@@ -35,7 +37,11 @@ const (
 			source varchar,
 			vType varchar,
 			vSource varchar,
+			severity varchar,
 			action varchar,
+			status varchar,
+			detail text,
+			reason text,
 			created_at timestamp,
 			PRIMARY KEY((namespace,type),created_at))
 			WITH CLUSTERING ORDER BY (created_at DESC)
@@ -49,7 +55,11 @@ const (
 			source varchar,
 			vType varchar,
 			vSource varchar,
+			severity varchar,
 			action varchar,
+			status varchar,
+			detail text,
+			reason text,
 			created_at timestamp,
 			PRIMARY KEY((type),created_at))
 			WITH CLUSTERING ORDER BY (created_at DESC)
@@ -62,7 +72,11 @@ const (
 			source varchar,
 			vType varchar,
 			vSource varchar,
+			severity varchar,
 			action varchar,
+			status varchar,
+			detail text,
+			reason text,
 			created_at timestamp,
 			PRIMARY KEY((vType),created_at))
 			WITH CLUSTERING ORDER BY (created_at DESC)
@@ -76,7 +90,11 @@ const (
 			source varchar,
 			vType varchar,
 			vSource varchar,
+			severity varchar,
 			action varchar,
+			status varchar,
+			detail text,
+			reason text,
 			created_at timestamp,
 			PRIMARY KEY((action),created_at))
 			WITH CLUSTERING ORDER BY (created_at DESC)
@@ -94,18 +112,282 @@ const (
 			actions frozen<map<varchar, list<timestamp>>>,
 			created_at timestamp,
 			expire_at timestamp,
+			state varchar,
+			state_at timestamp,
 			PRIMARY KEY((namespace,cluster,type,source,vtype,vsource),created_at))
 			WITH CLUSTERING ORDER BY (created_at desc)
 	`
 
-	INSERT_TO_VLOG = `INSERT INTO %s.vlog_namespace_type (namespace, cluster, type, source, vType, vSource, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	// Tracks the status of a violation in shadow mode, apart from the enforced ones
+	CREATE_SHADOW_VACTION_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.vaction_shadow (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			actions frozen<map<varchar, list<timestamp>>>,
+			created_at timestamp,
+			expire_at timestamp,
+			state varchar,
+			state_at timestamp,
+			PRIMARY KEY((namespace,cluster,type,source,vtype,vsource),created_at))
+			WITH CLUSTERING ORDER BY (created_at desc)
+	`
+
+	// What would have been done in shadow mode, partitioned by day for the report
+	CREATE_SHADOW_LOG_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.slog (
+			cluster varchar,
+			day varchar,
+			id timeuuid,
+			namespace varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			severity varchar,
+			action varchar,
+			status varchar,
+			detail text,
+			reason text,
+			created_at timestamp,
+			PRIMARY KEY((cluster,day),id))
+			WITH CLUSTERING ORDER BY (id DESC)
+	`
+
+	// Keeps the state of an entity before a reversible action, so it can be restored
+	CREATE_ENTITY_STATE_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.astate (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			remediation varchar,
+			state map<varchar, varchar>,
+			created_at timestamp,
+			restored_at timestamp,
+			PRIMARY KEY((namespace,cluster,type,source),created_at))
+			WITH CLUSTERING ORDER BY (created_at DESC)
+	`
+
+	// Keeps the manifest of an entity before it is deleted, so it can be applied again
+	CREATE_ARCHIVE_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.aarchive (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			manifest text,
+			created_at timestamp,
+			reapplied_at timestamp,
+			PRIMARY KEY((namespace,cluster),type,source,created_at))
+			WITH CLUSTERING ORDER BY (type ASC, source ASC, created_at DESC)
+	`
+
+	// The objects archived with an entity, e.g. the contents of a namespace, one row each so no row gets too large
+	CREATE_ARCHIVE_CONTENT_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.aarchive_content (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			archived_at timestamp,
+			kind varchar,
+			name varchar,
+			manifest text,
+			PRIMARY KEY((namespace,cluster,type,source,archived_at),kind,name))
+	`
+
+	// Violations that are not acted on until the exemption expires, any key column can be the * wildcard
+	CREATE_EXEMPTION_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.vexemption (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			reason text,
+			created_by varchar,
+			created_at timestamp,
+			expire_at timestamp,
+			PRIMARY KEY((cluster),namespace,type,source,vType,vSource))
+	`
+
+	// State of the circuit breaker of a cluster, blocked holds the destructive actions it stopped
+	CREATE_BREAKER_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.abreaker (
+			cluster varchar,
+			tripped_at timestamp,
+			reason text,
+			blocked set<text>,
+			reset_at timestamp,
+			reset_by varchar,
+			PRIMARY KEY(cluster))
+	`
+
+	// Destructive actions waiting for somebody to approve or reject them
+	CREATE_PENDING_ACTION_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.apending (
+			cluster varchar,
+			id timeuuid,
+			namespace varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			severity varchar,
+			remediation varchar,
+			status varchar,
+			created_at timestamp,
+			expire_at timestamp,
+			decided_by varchar,
+			decided_at timestamp,
+			reason text,
+			PRIMARY KEY((cluster),id))
+			WITH CLUSTERING ORDER BY (id DESC)
+	`
+
+	// How many entities were in each scope, counted once a day
+	CREATE_SCOPE_COVERAGE_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.scope_coverage (
+			cluster varchar,
+			day varchar,
+			scope varchar,
+			namespace varchar,
+			entities counter,
+			PRIMARY KEY((cluster,day),scope,namespace))
+	`
+
+	// The entities counted in scope_coverage, so each is counted once a day
+	CREATE_SCOPE_COVERAGE_ENTITY_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.scope_coverage_entity (
+			cluster varchar,
+			day varchar,
+			scope varchar,
+			namespace varchar,
+			type varchar,
+			source varchar,
+			PRIMARY KEY((cluster,day),scope,namespace,type,source))
+	`
+
+	// Actions taken on an entity, kept across expirations of its violations to escalate repeat offenders faster
+	CREATE_OFFENSE_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.aoffense (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			remediation varchar,
+			created_at timestamp,
+			PRIMARY KEY((namespace,cluster,type,source),created_at))
+			WITH CLUSTERING ORDER BY (created_at DESC)
+	`
+
+	// Every change of the lifecycle state of a violation, see actions.ViolationState
+	CREATE_TRANSITION_TABLE = `
+		CREATE TABLE IF NOT EXISTS %s.vtransition (
+			namespace varchar,
+			cluster varchar,
+			type varchar,
+			source varchar,
+			vType varchar,
+			vSource varchar,
+			from_state varchar,
+			to_state varchar,
+			trigger varchar,
+			created_at timestamp,
+			PRIMARY KEY((namespace,cluster),type,source,created_at,vType,vSource))
+			WITH CLUSTERING ORDER BY (type ASC, source ASC, created_at DESC, vType ASC, vSource ASC)
+	`
+
+	// Adds a column to a table created by an older version
+	ADD_COLUMN = `ALTER TABLE %s.%s ADD %s %s`
+
+	INSERT_TO_VLOG = `INSERT INTO %s.vlog_namespace_type (namespace, cluster, type, source, vType, vSource, severity, exemption, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	INSERT_TO_ALOG_NAMESPACE_TYPE = `INSERT INTO %s.alog_namespace_type (namespace, cluster, type, source, vType, vSource, severity, action, status, detail, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	INSERT_TO_ALOG_TYPE           = `INSERT INTO %s.alog_type (namespace, cluster, type, source, vType, vSource, severity, action, status, detail, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	INSERT_TO_ALOG_VTYPE          = `INSERT INTO %s.alog_vType (namespace, cluster, type, source, vType, vSource, severity, action, status, detail, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	INSERT_TO_ALOG_ACTION         = `INSERT INTO %s.alog_action (namespace, cluster, type, source, vType, vSource, severity, action, status, detail, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	INSERT_TO_VACTION = `INSERT INTO %s.vaction (namespace, cluster, type, source, vType, vSource, actions, created_at ,expire_at, state, state_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	INSERT_TO_SHADOW_VACTION = `INSERT INTO %s.vaction_shadow (namespace, cluster, type, source, vType, vSource, actions, created_at ,expire_at, state, state_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	SELECT_ALL_VACTIONS = `SELECT namespace, cluster, type, source, vType, vSource, actions, created_at, expire_at, state FROM %s.vaction`
+
+	UPDATE_VACTION_STATE = `UPDATE %s.vaction SET state = ?, state_at = ? WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND vType = ? AND vSource = ? AND created_at = ?`
+
+	INSERT_TO_TRANSITION = `INSERT INTO %s.vtransition (namespace, cluster, type, source, vType, vSource, from_state, to_state, trigger, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	SELECT_TRANSITIONS_IN_NAMESPACE = `SELECT namespace, type, source, vType, vSource, from_state, to_state, trigger, created_at FROM %s.vtransition WHERE namespace = ? AND cluster = ?`
+
+	SELECT_TRANSITIONS_OF_ENTITY = `SELECT namespace, type, source, vType, vSource, from_state, to_state, trigger, created_at FROM %s.vtransition WHERE namespace = ? AND cluster = ? AND type = ? AND source = ?`
+
+	INSERT_TO_SHADOW_LOG = `INSERT INTO %s.slog (cluster, day, id, namespace, type, source, vType, vSource, severity, action, status, detail, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+
+	SELECT_SHADOW_LOG_OF_DAY = `SELECT namespace, type, source, vType, vSource, severity, action, status, detail, reason, created_at FROM %s.slog WHERE cluster = ? AND day = ?`
+
+	INSERT_SCOPE_COVERAGE_ENTITY = `INSERT INTO %s.scope_coverage_entity (cluster, day, scope, namespace, type, source) VALUES (?, ?, ?, ?, ?, ?) IF NOT EXISTS`
+
+	INCREMENT_SCOPE_COVERAGE = `UPDATE %s.scope_coverage SET entities = entities + 1 WHERE cluster = ? AND day = ? AND scope = ? AND namespace = ?`
+
+	SELECT_SCOPE_COVERAGE_OF_DAY = `SELECT scope, namespace, entities FROM %s.scope_coverage WHERE cluster = ? AND day = ?`
+
+	INSERT_TO_ENTITY_STATE = `INSERT INTO %s.astate (namespace, cluster, type, source, vType, vSource, remediation, state, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	UPDATE_ENTITY_STATE_RESTORED = `UPDATE %s.astate SET restored_at = ? WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND created_at = ?`
+
+	SELECT_ENTITY_STATES = `SELECT namespace, type, source, vType, vSource, remediation, state, created_at, restored_at FROM %s.astate WHERE namespace = ? AND cluster = ? AND type = ? AND source = ?`
+
+	INSERT_TO_OFFENSE = `INSERT INTO %s.aoffense (namespace, cluster, type, source, vType, vSource, remediation, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+
+	SELECT_OFFENSES = `SELECT namespace, type, source, vType, vSource, remediation, created_at FROM %s.aoffense WHERE namespace = ? AND cluster = ? AND type = ? AND source = ?`
+
+	INSERT_TO_ARCHIVE = `INSERT INTO %s.aarchive (namespace, cluster, type, source, vType, vSource, manifest, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	INSERT_TO_ARCHIVE_CONTENT = `INSERT INTO %s.aarchive_content (namespace, cluster, type, source, archived_at, kind, name, manifest) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	SELECT_ARCHIVE_CONTENT = `SELECT kind, name, manifest FROM %s.aarchive_content WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND archived_at = ?`
+
+	UPDATE_ARCHIVE_REAPPLIED = `UPDATE %s.aarchive SET reapplied_at = ? WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND created_at = ?`
+
+	SELECT_ARCHIVES_IN_NAMESPACE = `SELECT namespace, type, source, vType, vSource, created_at, reapplied_at FROM %s.aarchive WHERE namespace = ? AND cluster = ?`
+
+	SELECT_LATEST_ARCHIVE = `SELECT namespace, type, source, vType, vSource, manifest, created_at, reapplied_at FROM %s.aarchive WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? LIMIT 1`
+
+	INSERT_TO_EXEMPTION = `INSERT INTO %s.vexemption (namespace, cluster, type, source, vType, vSource, reason, created_by, created_at, expire_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+
+	DELETE_EXEMPTION = `DELETE FROM %s.vexemption WHERE cluster = ? AND namespace = ? AND type = ? AND source = ? AND vType = ? AND vSource = ?`
+
+	SELECT_EXEMPTIONS = `SELECT namespace, cluster, type, source, vType, vSource, reason, created_by, created_at, expire_at FROM %s.vexemption WHERE cluster IN ?`
+
+	TRIP_BREAKER = `UPDATE %s.abreaker SET tripped_at = ?, reason = ? WHERE cluster = ?`
+
+	ADD_BREAKER_BLOCKED = `UPDATE %s.abreaker SET blocked = blocked + ? WHERE cluster = ?`
+
+	RESET_BREAKER = `UPDATE %s.abreaker SET tripped_at = null, reason = null, blocked = null, reset_at = ?, reset_by = ? WHERE cluster = ?`
+
+	SELECT_BREAKER = `SELECT tripped_at, reason, blocked, reset_at, reset_by FROM %s.abreaker WHERE cluster = ?`
+
+	INSERT_TO_PENDING_ACTION = `INSERT INTO %s.apending (cluster, id, namespace, type, source, vType, vSource, severity, remediation, status, created_at, expire_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`
+
+	UPDATE_PENDING_ACTION = `UPDATE %s.apending USING TTL ? SET status = ?, expire_at = ?, decided_by = ?, decided_at = ?, reason = ? WHERE cluster = ? AND id = ?`
+
+	SELECT_PENDING_ACTIONS = `SELECT id, namespace, type, source, vType, vSource, severity, remediation, status, created_at, expire_at, decided_by, decided_at, reason FROM %s.apending WHERE cluster = ?`
 
-	INSERT_TO_ALOG_NAMESPACE_TYPE = `INSERT INTO %s.alog_namespace_type (namespace, cluster, type, source, vType, vSource, action, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	INSERT_TO_ALOG_TYPE           = `INSERT INTO %s.alog_type (namespace, cluster, type, source, vType, vSource, action, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	INSERT_TO_ALOG_VTYPE          = `INSERT INTO %s.alog_vType (namespace, cluster, type, source, vType, vSource, action, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	INSERT_TO_ALOG_ACTION         = `INSERT INTO %s.alog_action (namespace, cluster, type, source, vType, vSource, action, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	SELECT_PENDING_ACTION = `SELECT id, namespace, type, source, vType, vSource, severity, remediation, status, created_at, expire_at, decided_by, decided_at, reason FROM %s.apending WHERE cluster = ? AND id = ?`
 
-	INSERT_TO_VACTION = `INSERT INTO %s.vaction (namespace, cluster, type, source, vType, vSource, actions, created_at ,expire_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	SELECT_ENTITY_FROM_VACTION = `SELECT namespace, type, source, vType, vSource, actions, created_at, expire_at, state, state_at FROM %s.vaction WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND vType = ? AND vSource = ? LIMIT 1`
 
-	SELECT_ENTITY_FROM_VACTION = `SELECT namespace, type, source, vType, vSource, actions, created_at, expire_at FROM %s.vaction WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND vType = ? AND vSource = ? LIMIT 1`
+	SELECT_ENTITY_FROM_SHADOW_VACTION = `SELECT namespace, type, source, vType, vSource, actions, created_at, expire_at, state, state_at FROM %s.vaction_shadow WHERE namespace = ? AND cluster = ? AND type = ? AND source = ? AND vType = ? AND vSource = ? LIMIT 1`
 )
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8guard/k8guard-action/db/stmts"
+
+	"github.com/gocql/gocql"
+	libs "github.com/k8guard/k8guardlibs"
+)
+
+func InsertTransitionRow(transition TransitionRow) {
+	err := Sess.Query(fmt.Sprintf(stmts.INSERT_TO_TRANSITION, libs.Cfg.CassandraKeyspace), transition.Namespace, libs.Cfg.ClusterName,
+		transition.Type, transition.Source, transition.VType, transition.VSource, transition.From, transition.To, transition.Trigger, transition.CreatedAt).Exec()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns the transitions of the violations of an entity, or of every entity in the namespace when entityType is empty.
+// They are ordered by entity and then the latest first.
+func SelectTransitionRows(namespace string, entityType string, entitySource string) []TransitionRow {
+	var iter *gocql.Iter
+	if len(entityType) == 0 {
+		iter = Sess.Query(fmt.Sprintf(stmts.SELECT_TRANSITIONS_IN_NAMESPACE, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName).Iter()
+	} else {
+		iter = Sess.Query(fmt.Sprintf(stmts.SELECT_TRANSITIONS_OF_ENTITY, libs.Cfg.CassandraKeyspace), namespace, libs.Cfg.ClusterName, entityType, entitySource).Iter()
+	}
+
+	transitionRows := []TransitionRow{}
+	transitionRow := TransitionRow{}
+	for iter.Scan(&transitionRow.Namespace, &transitionRow.Type, &transitionRow.Source, &transitionRow.VType, &transitionRow.VSource,
+		&transitionRow.From, &transitionRow.To, &transitionRow.Trigger, &transitionRow.CreatedAt) {
+		transitionRows = append(transitionRows, transitionRow)
+		transitionRow = TransitionRow{}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+	return transitionRows
+}
+
+// MigrateVactionStates gives the latest row of every violation of the cluster without a lifecycle state the state
+// stateOf returns and records the transition to it. Expired rows get the resolved state at their expiry.
+// Returns how many violations were migrated.
+func MigrateVactionStates(stateOf func(actions map[string][]time.Time) string, resolved string, trigger string) int {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_ALL_VACTIONS, libs.Cfg.CassandraKeyspace)).Iter()
+
+	migrated := 0
+	lastKey := ""
+	var cluster string
+	vActionRow := VActionRow{Actions: map[string][]time.Time{}}
+	for iter.Scan(&vActionRow.Namespace, &cluster, &vActionRow.Type, &vActionRow.Source, &vActionRow.VType, &vActionRow.VSource,
+		&vActionRow.Actions, &vActionRow.CreatedAt, &vActionRow.ExpiresAt, &vActionRow.State) {
+		// the rows of a violation come together, the latest first
+		key := fmt.Sprint(vActionRow.Namespace, "/", cluster, "/", vActionRow.Type, "/", vActionRow.Source, "/", vActionRow.VType, "/", vActionRow.VSource)
+		latest := key != lastKey
+		lastKey = key
+
+		if latest && cluster == libs.Cfg.ClusterName && len(vActionRow.State) == 0 {
+			transition := TransitionRow{
+				Namespace: vActionRow.Namespace,
+				Type:      vActionRow.Type,
+				Source:    vActionRow.Source,
+				VType:     vActionRow.VType,
+				VSource:   vActionRow.VSource,
+				To:        stateOf(vActionRow.Actions),
+				Trigger:   trigger,
+				CreatedAt: time.Now(),
+			}
+			if vActionRow.ExpiresAt.Before(time.Now()) {
+				transition.To = resolved
+				transition.CreatedAt = vActionRow.ExpiresAt
+			}
+			err := Sess.Query(fmt.Sprintf(stmts.UPDATE_VACTION_STATE, libs.Cfg.CassandraKeyspace), transition.To, transition.CreatedAt,
+				vActionRow.Namespace, cluster, vActionRow.Type, vActionRow.Source, vActionRow.VType, vActionRow.VSource, vActionRow.CreatedAt).Exec()
+			if err != nil {
+				panic(err)
+			}
+			InsertTransitionRow(transition)
+			migrated++
+		}
+		vActionRow = VActionRow{Actions: map[string][]time.Time{}}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+	return migrated
+}
+
+// SelectExpiredVactionRows returns the violations of the cluster whose latest row expired in another state than
+// ended, with the state in ExpiredState and the expiry in ExpiredAt.
+func SelectExpiredVactionRows(ended string) []VActionRow {
+	iter := Sess.Query(fmt.Sprintf(stmts.SELECT_ALL_VACTIONS, libs.Cfg.CassandraKeyspace)).Iter()
+
+	expired := []VActionRow{}
+	lastKey := ""
+	var cluster string
+	vActionRow := VActionRow{Actions: map[string][]time.Time{}}
+	for iter.Scan(&vActionRow.Namespace, &cluster, &vActionRow.Type, &vActionRow.Source, &vActionRow.VType, &vActionRow.VSource,
+		&vActionRow.Actions, &vActionRow.CreatedAt, &vActionRow.ExpiresAt, &vActionRow.State) {
+		// the rows of a violation come together, the latest first
+		key := fmt.Sprint(vActionRow.Namespace, "/", cluster, "/", vActionRow.Type, "/", vActionRow.Source, "/", vActionRow.VType, "/", vActionRow.VSource)
+		latest := key != lastKey
+		lastKey = key
+
+		if latest && cluster == libs.Cfg.ClusterName && vActionRow.State != ended && vActionRow.ExpiresAt.Before(time.Now()) {
+			vActionRow.ExpiredState = vActionRow.State
+			vActionRow.ExpiredAt = vActionRow.ExpiresAt
+			expired = append(expired, vActionRow)
+		}
+		vActionRow = VActionRow{Actions: map[string][]time.Time{}}
+	}
+
+	if err := iter.Close(); err != nil {
+		panic(err)
+	}
+	return expired
+}
@@ -0,0 +1,287 @@
+package kube
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	libs "github.com/k8guard/k8guardlibs"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1beta1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	batchv2alpha1listers "k8s.io/client-go/listers/batch/v2alpha1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	extensionslisters "k8s.io/client-go/listers/extensions/v1beta1"
+	"k8s.io/client-go/pkg/api/v1"
+	appsv1beta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ObjectCache looks up the objects that are read on every action, namespaces for notifications,
+// the owners of pods and the entities for their labels and creation time. The returned objects are shared
+// and must not be modified.
+type ObjectCache interface {
+	Namespace(name string) (*v1.Namespace, error)
+	Pod(namespace string, name string) (*v1.Pod, error)
+	ReplicaSet(namespace string, name string) (*v1beta1.ReplicaSet, error)
+	Job(namespace string, name string) (*batchv1.Job, error)
+	Deployment(namespace string, name string) (*appsv1beta1.Deployment, error)
+	DaemonSet(namespace string, name string) (*v1beta1.DaemonSet, error)
+	StatefulSet(namespace string, name string) (*appsv1beta1.StatefulSet, error)
+	ReplicationController(namespace string, name string) (*v1.ReplicationController, error)
+	CronJob(namespace string, name string) (*batchv2alpha1.CronJob, error)
+	Ingress(namespace string, name string) (*v1beta1.Ingress, error)
+}
+
+var (
+	objectCache      ObjectCache = liveCache{}
+	objectCacheMutex sync.RWMutex
+)
+
+// Cache returns the shared object cache, until StartInformerCache is called it reads from the API server.
+func Cache() ObjectCache {
+	objectCacheMutex.RLock()
+	defer objectCacheMutex.RUnlock()
+	return objectCache
+}
+
+// SetCache replaces the shared object cache, e.g. with a FakeCache in tests.
+func SetCache(c ObjectCache) {
+	objectCacheMutex.Lock()
+	defer objectCacheMutex.Unlock()
+	objectCache = c
+}
+
+// StartInformerCache starts the shared informers and uses them as the object cache once they are synced.
+func StartInformerCache(resync time.Duration, stop <-chan struct{}) error {
+	clientset, err := Clientset()
+	if err != nil {
+		return err
+	}
+
+	factory := informers.NewSharedInformerFactory(clientset, resync)
+	c := informerCache{
+		namespaces:             factory.Core().V1().Namespaces().Lister(),
+		pods:                   factory.Core().V1().Pods().Lister(),
+		replicaSets:            factory.Extensions().V1beta1().ReplicaSets().Lister(),
+		jobs:                   factory.Batch().V1().Jobs().Lister(),
+		deployments:            factory.Apps().V1beta1().Deployments().Lister(),
+		daemonSets:             factory.Extensions().V1beta1().DaemonSets().Lister(),
+		statefulSets:           factory.Apps().V1beta1().StatefulSets().Lister(),
+		replicationControllers: factory.Core().V1().ReplicationControllers().Lister(),
+		ingresses:              factory.Extensions().V1beta1().Ingresses().Lister(),
+	}
+	hasSynced := []cache.InformerSynced{
+		factory.Core().V1().Namespaces().Informer().HasSynced,
+		factory.Core().V1().Pods().Informer().HasSynced,
+		factory.Extensions().V1beta1().ReplicaSets().Informer().HasSynced,
+		factory.Batch().V1().Jobs().Informer().HasSynced,
+		factory.Apps().V1beta1().Deployments().Informer().HasSynced,
+		factory.Extensions().V1beta1().DaemonSets().Informer().HasSynced,
+		factory.Apps().V1beta1().StatefulSets().Informer().HasSynced,
+		factory.Core().V1().ReplicationControllers().Informer().HasSynced,
+		factory.Extensions().V1beta1().Ingresses().Informer().HasSynced,
+	}
+	// the cron job API only exists in clusters with alpha features
+	if libs.Cfg.IncludeAlpha {
+		c.cronJobs = factory.Batch().V2alpha1().CronJobs().Lister()
+		hasSynced = append(hasSynced, factory.Batch().V2alpha1().CronJobs().Informer().HasSynced)
+	}
+	factory.Start(stop)
+
+	libs.Log.Info("Waiting for the informer cache to sync")
+	synced := cache.WaitForCacheSync(stop, hasSynced...)
+	if !synced {
+		return errors.New("The informer cache did not sync")
+	}
+
+	SetCache(c)
+	libs.Log.Info("Using the informer cache")
+	return nil
+}
+
+type liveCache struct{}
+
+func (liveCache) Namespace(name string) (*v1.Namespace, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) Pod(namespace string, name string) (*v1.Pod, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) ReplicaSet(namespace string, name string) (*v1beta1.ReplicaSet, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.ExtensionsV1beta1().ReplicaSets(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) Job(namespace string, name string) (*batchv1.Job, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.BatchV1().Jobs(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) Deployment(namespace string, name string) (*appsv1beta1.Deployment, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.AppsV1beta1().Deployments(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) DaemonSet(namespace string, name string) (*v1beta1.DaemonSet, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.ExtensionsV1beta1().DaemonSets(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) StatefulSet(namespace string, name string) (*appsv1beta1.StatefulSet, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.AppsV1beta1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) ReplicationController(namespace string, name string) (*v1.ReplicationController, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.CoreV1().ReplicationControllers(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) CronJob(namespace string, name string) (*batchv2alpha1.CronJob, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.BatchV2alpha1().CronJobs(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (liveCache) Ingress(namespace string, name string) (*v1beta1.Ingress, error) {
+	clientset, err := Clientset()
+	if err != nil {
+		return nil, err
+	}
+	return clientset.ExtensionsV1beta1().Ingresses(namespace).Get(name, metav1.GetOptions{})
+}
+
+// informerCache falls back to the API server for objects that are not in the cache yet,
+// e.g. a pod that was created after the last watch event.
+type informerCache struct {
+	namespaces             corelisters.NamespaceLister
+	pods                   corelisters.PodLister
+	replicaSets            extensionslisters.ReplicaSetLister
+	jobs                   batchlisters.JobLister
+	deployments            appslisters.DeploymentLister
+	daemonSets             extensionslisters.DaemonSetLister
+	statefulSets           appslisters.StatefulSetLister
+	replicationControllers corelisters.ReplicationControllerLister
+	ingresses              extensionslisters.IngressLister
+	// nil without alpha features
+	cronJobs batchv2alpha1listers.CronJobLister
+}
+
+func (c informerCache) Namespace(name string) (*v1.Namespace, error) {
+	ns, err := c.namespaces.Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.Namespace(name)
+	}
+	return ns, err
+}
+
+func (c informerCache) Pod(namespace string, name string) (*v1.Pod, error) {
+	pod, err := c.pods.Pods(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.Pod(namespace, name)
+	}
+	return pod, err
+}
+
+func (c informerCache) ReplicaSet(namespace string, name string) (*v1beta1.ReplicaSet, error) {
+	rs, err := c.replicaSets.ReplicaSets(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.ReplicaSet(namespace, name)
+	}
+	return rs, err
+}
+
+func (c informerCache) Job(namespace string, name string) (*batchv1.Job, error) {
+	job, err := c.jobs.Jobs(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.Job(namespace, name)
+	}
+	return job, err
+}
+
+func (c informerCache) Deployment(namespace string, name string) (*appsv1beta1.Deployment, error) {
+	d, err := c.deployments.Deployments(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.Deployment(namespace, name)
+	}
+	return d, err
+}
+
+func (c informerCache) DaemonSet(namespace string, name string) (*v1beta1.DaemonSet, error) {
+	ds, err := c.daemonSets.DaemonSets(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.DaemonSet(namespace, name)
+	}
+	return ds, err
+}
+
+func (c informerCache) StatefulSet(namespace string, name string) (*appsv1beta1.StatefulSet, error) {
+	ss, err := c.statefulSets.StatefulSets(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.StatefulSet(namespace, name)
+	}
+	return ss, err
+}
+
+func (c informerCache) ReplicationController(namespace string, name string) (*v1.ReplicationController, error) {
+	rc, err := c.replicationControllers.ReplicationControllers(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.ReplicationController(namespace, name)
+	}
+	return rc, err
+}
+
+func (c informerCache) CronJob(namespace string, name string) (*batchv2alpha1.CronJob, error) {
+	if c.cronJobs == nil {
+		return liveCache{}.CronJob(namespace, name)
+	}
+	cj, err := c.cronJobs.CronJobs(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.CronJob(namespace, name)
+	}
+	return cj, err
+}
+
+func (c informerCache) Ingress(namespace string, name string) (*v1beta1.Ingress, error) {
+	ing, err := c.ingresses.Ingresses(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return liveCache{}.Ingress(namespace, name)
+	}
+	return ing, err
+}
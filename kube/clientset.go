@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/k8guard/k8guard-action/config"
+
+	libs "github.com/k8guard/k8guardlibs"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+)
+
+var (
+	clientset      kubernetes.Interface
+	clientsetMutex sync.Mutex
+)
+
+// Clientset returns the clientset shared by all actions, it is built on first use
+// with the QPS and burst from the config.
+func Clientset() (kubernetes.Interface, error) {
+	clientsetMutex.Lock()
+	defer clientsetMutex.Unlock()
+
+	if clientset != nil {
+		return clientset, nil
+	}
+
+	restConfig, err := loadRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	restConfig.QPS = config.Cfg.KubeQPS
+	restConfig.Burst = config.Cfg.KubeBurst
+
+	cs, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	libs.Log.Info("Created the Kubernetes clientset with qps ", restConfig.QPS, " and burst ", restConfig.Burst)
+	clientset = cs
+	return clientset, nil
+}
+
+// SetClientset replaces the shared clientset, e.g. with a fake clientset in tests.
+func SetClientset(cs kubernetes.Interface) {
+	clientsetMutex.Lock()
+	defer clientsetMutex.Unlock()
+	clientset = cs
+}
+
+// the in cluster config, or the kube config of the user when running outside of the cluster
+func loadRestConfig() (*rest.Config, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err == nil {
+		return restConfig, nil
+	}
+
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if len(kubeconfig) == 0 {
+		kubeconfig = filepath.Join(homedir.HomeDir(), ".kube", "config")
+	}
+	libs.Log.Debug("Not running in a cluster (", err, "), using ", kubeconfig)
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
@@ -0,0 +1,111 @@
+package kube
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/pkg/api/v1"
+	appsv1beta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	batchv2alpha1 "k8s.io/client-go/pkg/apis/batch/v2alpha1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// FakeCache is an in memory ObjectCache for tests, objects are keyed by namespace/name
+// and namespaces by their name. Missing objects are not found errors like with the API server.
+type FakeCache struct {
+	Namespaces             map[string]*v1.Namespace
+	Pods                   map[string]*v1.Pod
+	ReplicaSets            map[string]*v1beta1.ReplicaSet
+	Jobs                   map[string]*batchv1.Job
+	Deployments            map[string]*appsv1beta1.Deployment
+	DaemonSets             map[string]*v1beta1.DaemonSet
+	StatefulSets           map[string]*appsv1beta1.StatefulSet
+	ReplicationControllers map[string]*v1.ReplicationController
+	CronJobs               map[string]*batchv2alpha1.CronJob
+	Ingresses              map[string]*v1beta1.Ingress
+}
+
+func NewFakeCache() *FakeCache {
+	return &FakeCache{
+		Namespaces:             map[string]*v1.Namespace{},
+		Pods:                   map[string]*v1.Pod{},
+		ReplicaSets:            map[string]*v1beta1.ReplicaSet{},
+		Jobs:                   map[string]*batchv1.Job{},
+		Deployments:            map[string]*appsv1beta1.Deployment{},
+		DaemonSets:             map[string]*v1beta1.DaemonSet{},
+		StatefulSets:           map[string]*appsv1beta1.StatefulSet{},
+		ReplicationControllers: map[string]*v1.ReplicationController{},
+		CronJobs:               map[string]*batchv2alpha1.CronJob{},
+		Ingresses:              map[string]*v1beta1.Ingress{},
+	}
+}
+
+func (c *FakeCache) Namespace(name string) (*v1.Namespace, error) {
+	if ns, ok := c.Namespaces[name]; ok {
+		return ns, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, name)
+}
+
+func (c *FakeCache) Pod(namespace string, name string) (*v1.Pod, error) {
+	if pod, ok := c.Pods[namespace+"/"+name]; ok {
+		return pod, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+}
+
+func (c *FakeCache) ReplicaSet(namespace string, name string) (*v1beta1.ReplicaSet, error) {
+	if rs, ok := c.ReplicaSets[namespace+"/"+name]; ok {
+		return rs, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "extensions", Resource: "replicasets"}, name)
+}
+
+func (c *FakeCache) Job(namespace string, name string) (*batchv1.Job, error) {
+	if job, ok := c.Jobs[namespace+"/"+name]; ok {
+		return job, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "batch", Resource: "jobs"}, name)
+}
+
+func (c *FakeCache) Deployment(namespace string, name string) (*appsv1beta1.Deployment, error) {
+	if d, ok := c.Deployments[namespace+"/"+name]; ok {
+		return d, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, name)
+}
+
+func (c *FakeCache) DaemonSet(namespace string, name string) (*v1beta1.DaemonSet, error) {
+	if ds, ok := c.DaemonSets[namespace+"/"+name]; ok {
+		return ds, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "extensions", Resource: "daemonsets"}, name)
+}
+
+func (c *FakeCache) StatefulSet(namespace string, name string) (*appsv1beta1.StatefulSet, error) {
+	if ss, ok := c.StatefulSets[namespace+"/"+name]; ok {
+		return ss, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "statefulsets"}, name)
+}
+
+func (c *FakeCache) ReplicationController(namespace string, name string) (*v1.ReplicationController, error) {
+	if rc, ok := c.ReplicationControllers[namespace+"/"+name]; ok {
+		return rc, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "replicationcontrollers"}, name)
+}
+
+func (c *FakeCache) CronJob(namespace string, name string) (*batchv2alpha1.CronJob, error) {
+	if cj, ok := c.CronJobs[namespace+"/"+name]; ok {
+		return cj, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "batch", Resource: "cronjobs"}, name)
+}
+
+func (c *FakeCache) Ingress(namespace string, name string) (*v1beta1.Ingress, error) {
+	if ing, ok := c.Ingresses[namespace+"/"+name]; ok {
+		return ing, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "extensions", Resource: "ingresses"}, name)
+}
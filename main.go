@@ -1,7 +1,13 @@
 package main
 
 import (
+	"os"
+
+	"github.com/k8guard/k8guard-action/actions"
+	"github.com/k8guard/k8guard-action/api"
+	"github.com/k8guard/k8guard-action/config"
 	"github.com/k8guard/k8guard-action/db"
+	"github.com/k8guard/k8guard-action/kube"
 	"github.com/k8guard/k8guard-action/messaging"
 
 	libs "github.com/k8guard/k8guardlibs"
@@ -10,10 +16,73 @@ import (
 func main() {
 	libs.Log.Info("Hello From k8guard-action")
 
-	err := db.Connect(libs.Cfg.CassandraHosts)
+	err := actions.LoadRemediations(config.Cfg.Remediations)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	err = actions.LoadEscalationPolicy(config.Cfg.EscalationPolicyFile)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	err = actions.LoadScope(config.Cfg.ScopeFile)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	err = actions.LoadDecisionPolicy(config.Cfg.DecisionPolicyPath)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	err = actions.LoadRollout(config.Cfg.RolloutPercent)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	err = actions.LoadEnforcementSchedule(config.Cfg.EnforcementWindows, config.Cfg.ChangeFreezes, config.Cfg.EnforcementTimezone)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	err = db.Connect(libs.Cfg.CassandraHosts)
 	if err != nil {
 		panic(err.Error())
 	}
+
+	if len(os.Args) > 1 {
+		err = runCommand(os.Args[1], os.Args[2:])
+		if err != nil {
+			libs.Log.Fatal(err)
+		}
+		return
+	}
+
+	err = kube.StartInformerCache(config.Cfg.CacheResyncInterval, make(chan struct{}))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	go actions.WatchQuarantines(config.Cfg.QuarantineSweepInterval)
+	go actions.WatchExpiredViolations(config.Cfg.ExpirySweepInterval)
+	go actions.WatchDecisionPolicy(config.Cfg.DecisionPolicyPath, config.Cfg.DecisionPolicyReloadInterval)
+
+	if len(config.Cfg.APIAddress) > 0 {
+		go func() {
+			panic(api.Serve(config.Cfg.APIAddress, config.Cfg.APITokens))
+		}()
+	}
+
+	actions.SyncExemptions()
+	go actions.WatchExemptions(config.Cfg.ExemptionSyncInterval)
+
+	actions.SyncPolicyResources()
+	go actions.WatchPolicyResources(config.Cfg.PolicyResourceSyncInterval)
+
+	actions.SyncCircuitBreaker()
+	go actions.WatchCircuitBreaker(config.Cfg.BreakerCheckInterval)
+
 	messaging.ConsumeMessages()
 
 }
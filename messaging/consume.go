@@ -7,14 +7,23 @@ import (
 
 	"encoding/json"
 	"reflect"
+	"time"
 
 	"github.com/k8guard/k8guard-action/actions"
+	"github.com/k8guard/k8guard-action/config"
 
 	"github.com/k8guard/k8guard-action/db"
 
 	"github.com/k8guard/k8guardlibs/violations"
 )
 
+// Kinds that k8guardlibs has no message type for yet.
+const (
+	STATEFULSET_MESSAGE           = "STATEFULSET"
+	REPLICASET_MESSAGE            = "REPLICASET"
+	REPLICATIONCONTROLLER_MESSAGE = "REPLICATIONCONTROLLER"
+)
+
 func ConsumeMessages() {
 
 	c, err := messaging.CreateMessageConsumer(
@@ -57,7 +66,8 @@ func parseViolationMessage(msg []byte) {
 
 		pod := actions.ActionPod{}
 		json.Unmarshal(dataBytes, &pod)
-		actionableEntity = pod
+		// act on the controller, violations of its pods share one escalation
+		actionableEntity = actions.ResolveController(pod)
 
 		entityViolations = append(entityViolations, pod.Violations...)
 
@@ -121,11 +131,49 @@ func parseViolationMessage(msg []byte) {
 
 		entityViolations = append(entityViolations, cronjob.Violations...)
 		break
+	case STATEFULSET_MESSAGE:
+		libs.Log.Debug("Parsing StatefulSet Message")
+
+		statefulSet := actions.ActionStatefulSet{}
+		json.Unmarshal(dataBytes, &statefulSet)
+		actionableEntity = statefulSet
+
+		entityViolations = append(entityViolations, statefulSet.Violations...)
+		break
+	case REPLICASET_MESSAGE:
+		libs.Log.Debug("Parsing ReplicaSet Message")
+
+		replicaSet := actions.ActionReplicaSet{}
+		json.Unmarshal(dataBytes, &replicaSet)
+		actionableEntity = replicaSet
+
+		entityViolations = append(entityViolations, replicaSet.Violations...)
+		break
+	case REPLICATIONCONTROLLER_MESSAGE:
+		libs.Log.Debug("Parsing ReplicationController Message")
+
+		replicationController := actions.ActionReplicationController{}
+		json.Unmarshal(dataBytes, &replicationController)
+		actionableEntity = replicationController
+
+		entityViolations = append(entityViolations, replicationController.Violations...)
+		break
 	default:
 		libs.Log.Error("Unknown Message Kind: ", messageData["kind"])
 		return
 	}
 
+	// Counted so the coverage of the scope can be seen
+	scopedEntity, err := actions.ConvertActionableEntityToViolatableEntity(actionableEntity)
+	if err != nil {
+		libs.Log.Fatal(err)
+	}
+	scope := actions.ScopeOf(actionableEntity, scopedEntity.Namespace)
+	db.IncrementScopeCoverage(string(scope), scopedEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), scopedEntity.Name)
+	if scope != actions.ActScope {
+		libs.Log.Info(reflect.TypeOf(actionableEntity).Name(), " ", scopedEntity.Name, " in namespace ", scopedEntity.Namespace, " is in the ", scope, " scope")
+	}
+
 	for _, violation := range entityViolations {
 
 		vEntity, err := actions.ConvertActionableEntityToViolatableEntity(actionableEntity)
@@ -133,34 +181,76 @@ func parseViolationMessage(msg []byte) {
 			libs.Log.Fatal(err)
 		}
 
+		severity := string(actions.SeverityOf(violation.Type))
+		exemption, exempted := actions.ExemptionOf(vEntity, violation, reflect.TypeOf(actionableEntity).Name())
+		if !exempted {
+			exemption, exempted = actions.PolicyExemptionOf(vEntity, violation, reflect.TypeOf(actionableEntity).Name())
+		}
+		if !exempted {
+			exemption, exempted = actions.NamespaceExemptionOf(vEntity, violation, reflect.TypeOf(actionableEntity).Name())
+		}
+		if exempted {
+			// Logged with the exemption but not acted on
+			libs.Log.Info("Violation ", violation.Type, " of ", vEntity.Name, " in namespace ", vEntity.Namespace, " is ", exemption)
+			db.InsertVLOGRow(vEntity, violation, reflect.TypeOf(actionableEntity).Name(), severity, exemption.String())
+			actions.ExemptViolation(vEntity, violation, reflect.TypeOf(actionableEntity).Name(), libs.Cfg.ActionDryRun)
+			continue
+		}
+
 		// Insert violation into log
-		db.InsertVLOGRow(vEntity, violation, reflect.TypeOf(actionableEntity).Name())
+		db.InsertVLOGRow(vEntity, violation, reflect.TypeOf(actionableEntity).Name(), severity, "")
+		if scope == actions.LogScope {
+			continue
+		}
 		action := createAction(violation)
 		vActionRow := db.SelectVActionRow(vEntity, violation, reflect.TypeOf(actionableEntity).Name())
-		doneActions := actions.DoAction(action, actionableEntity, vEntity, vActionRow.Actions, libs.Cfg.ActionDryRun)
+		if libs.Cfg.ActionDryRun {
+			vActionRow = db.SelectShadowVActionRow(vEntity, violation, reflect.TypeOf(actionableEntity).Name())
+		}
+		state, stateAt := actions.CurrentState(vEntity, violation, reflect.TypeOf(actionableEntity).Name(), vActionRow, libs.Cfg.ActionDryRun)
+		doneActions := actions.DoAction(action, actionableEntity, vEntity, vActionRow.Actions, state, libs.Cfg.ActionDryRun, scope)
 
-		if len(doneActions) == 0 {
-			// If we did no actions don't insert anything
-			return
+		if len(doneActions) == 0 && state == actions.ViolationState(vActionRow.State) && (vActionRow.CreatedAt.IsZero() || time.Since(vActionRow.CreatedAt) < libs.Cfg.DurationViolationExpires/2) {
+			// If we did no actions and the state is unchanged don't insert anything,
+			// unless the row would expire while the violation is still reported
+			continue
 		}
+		state, stateAt = actions.AdvanceState(vEntity, violation, reflect.TypeOf(actionableEntity).Name(), state, stateAt, doneActions, libs.Cfg.ActionDryRun)
 
-		for actionName, t := range doneActions {
+		for _, doneAction := range doneActions {
 
 			// Insert action into log
-			db.InsertActionLogRow(vEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), vEntity.Name, string(violation.Type), violation.Source, actionName)
+			if libs.Cfg.ActionDryRun {
+				db.InsertShadowLogRow(vEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), vEntity.Name, string(violation.Type), violation.Source, severity, doneAction.Name, string(doneAction.Status), doneAction.Detail, doneAction.Reason, config.Cfg.ShadowLogRetention)
+			} else {
+				db.InsertActionLogRow(vEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), vEntity.Name, string(violation.Type), violation.Source, severity, doneAction.Name, string(doneAction.Status), doneAction.Detail, doneAction.Reason)
+			}
 
-			if _, ok := vActionRow.Actions[actionName]; ok {
-				vActionRow.Actions[actionName] = append(vActionRow.Actions[actionName], t...)
+			if doneAction.Status.Retry() {
+				// Not counted as done so it is tried again on the next scan
+				libs.Log.Warn("Action ", doneAction.Name, " on ", vEntity.Name, " ended with ", doneAction.Status, ", will retry")
+				continue
+			}
+
+			if _, ok := vActionRow.Actions[doneAction.Name]; ok {
+				vActionRow.Actions[doneAction.Name] = append(vActionRow.Actions[doneAction.Name], doneAction.At)
 			} else {
-				vActionRow.Actions[actionName] = t
+				vActionRow.Actions[doneAction.Name] = []time.Time{doneAction.At}
 			}
 		}
 
 		// Insert violation state
-		db.InsertVactionRow(vEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), vEntity.Name, string(violation.Type), violation.Source, vActionRow.Actions)
+		if libs.Cfg.ActionDryRun {
+			db.InsertShadowVactionRow(vEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), vEntity.Name, string(violation.Type), violation.Source, vActionRow.Actions, string(state), stateAt)
+		} else {
+			db.InsertVactionRow(vEntity.Namespace, reflect.TypeOf(actionableEntity).Name(), vEntity.Name, string(violation.Type), violation.Source, vActionRow.Actions, string(state), stateAt)
+		}
 
 	}
 
+	if !libs.Cfg.ActionDryRun {
+		actions.ReleaseFixedQuarantines(actionableEntity, scopedEntity, entityViolations)
+	}
 }
 
 func createAction(violation violations.Violation) actions.Action {